@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Invocation is a method call on a proxied service.
+type Invocation interface {
+	// Target returns the service the call is made on.
+	Target() any
+	// Method returns the name of the method called.
+	Method() string
+	// Args returns the arguments of the call, interceptors may change them
+	// before calling Proceed.
+	Args() []any
+	// Proceed calls the next interceptor, or the method of the target after
+	// the last one, and returns the results.
+	Proceed() []any
+}
+
+// Interceptor is an advice run around every method call of a proxied service,
+// it returns the results of the call, usually the ones of inv.Proceed().
+// Interceptors are passed to As next to the interfaces they apply to:
+//
+//	wheels.Provide(NewRepo, wheels.As(new(Repo), wheels.Around(logCalls)))
+type Interceptor func(inv Invocation) []any
+
+// Around returns an interceptor running fn in place of the call.
+func Around(fn func(inv Invocation) []any) Interceptor {
+	return fn
+}
+
+// Before returns an interceptor running fn before the call.
+func Before(fn func(inv Invocation)) Interceptor {
+	return func(inv Invocation) []any {
+		fn(inv)
+		return inv.Proceed()
+	}
+}
+
+// After returns an interceptor running fn with the results of the call.
+func After(fn func(inv Invocation, results []any)) Interceptor {
+	return func(inv Invocation) []any {
+		results := inv.Proceed()
+		fn(inv, results)
+		return results
+	}
+}
+
+// ProxyHandler is called by a proxy for every method call with the arguments
+// of the call, it returns the results of the call. A variadic argument is
+// passed as a single slice.
+type ProxyHandler func(method string, args ...any) []any
+
+var proxyFactories sync.Map // reflect.Type -> func(ProxyHandler) reflect.Value
+
+// RegisterProxy registers the proxy factory of the interface T, which is
+// needed to intercept services provided as T. A proxy implements every method
+// of T by forwarding it to the handler, it can be generated with
+// cmd/wheels-proxy or written by hand:
+//
+//	type repoProxy struct{ h wheels.ProxyHandler }
+//
+//	func (p repoProxy) Get(id int) (*User, error) {
+//		out := p.h("Get", id)
+//		u, _ := out[0].(*User)
+//		err, _ := out[1].(error)
+//		return u, err
+//	}
+//
+//	func init() {
+//		wheels.RegisterProxy(func(h wheels.ProxyHandler) Repo { return repoProxy{h} })
+//	}
+func RegisterProxy[T any](factory func(h ProxyHandler) T) {
+	typ := reflect.TypeOf(new(T)).Elem()
+	proxyFactories.Store(typ, func(h ProxyHandler) reflect.Value {
+		p := factory(h)
+		return reflect.ValueOf(&p).Elem()
+	})
+}
+
+func lookupProxyFactory(iface reflect.Type) (func(ProxyHandler) reflect.Value, bool) {
+	f, ok := proxyFactories.Load(iface)
+	if !ok {
+		return nil, false
+	}
+	return f.(func(ProxyHandler) reflect.Value), true
+}
+
+// MakeProxy returns the proxy of the interface iface forwarding every method
+// call to h, made by the factory registered for iface with RegisterProxy.
+// It lets tests stub an interface without writing an implementation.
+func MakeProxy(iface reflect.Type, h ProxyHandler) (any, error) {
+	factory, ok := lookupProxyFactory(iface)
+	if !ok {
+		return nil, fmt.Errorf("as: %v, err: %w", iface.String(), ErrProxyNotRegistered)
+	}
+	return factory(h).Interface(), nil
+}
+
+// newProxy returns a proxy of the interface iface running interceptors around
+// the calls to target.
+func newProxy(iface reflect.Type, target reflect.Value, interceptors []Interceptor) (reflect.Value, error) {
+	factory, ok := lookupProxyFactory(iface)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("as: %v, err: %w", iface.String(), ErrProxyNotRegistered)
+	}
+	return factory(func(method string, args ...any) []any {
+		inv := &invocation{
+			target:       target,
+			method:       method,
+			args:         args,
+			interceptors: interceptors,
+		}
+		return inv.Proceed()
+	}), nil
+}
+
+type invocation struct {
+	target       reflect.Value
+	method       string
+	args         []any
+	interceptors []Interceptor
+}
+
+func (inv *invocation) Target() any {
+	return inv.target.Interface()
+}
+
+func (inv *invocation) Method() string {
+	return inv.method
+}
+
+func (inv *invocation) Args() []any {
+	return inv.args
+}
+
+func (inv *invocation) Proceed() []any {
+	if len(inv.interceptors) > 0 {
+		next := *inv
+		next.interceptors = inv.interceptors[1:]
+		return inv.interceptors[0](&next)
+	}
+	return callMethod(inv.target, inv.method, inv.args)
+}
+
+// callMethod calls the method of target with args, nil args are passed as
+// the zero value of their param.
+func callMethod(target reflect.Value, method string, args []any) []any {
+	m := target.MethodByName(method)
+	mtype := m.Type()
+	in := make([]reflect.Value, len(args))
+	for j, arg := range args {
+		if arg == nil {
+			in[j] = reflect.Zero(mtype.In(j))
+		} else {
+			in[j] = reflect.ValueOf(arg)
+		}
+	}
+	var out []reflect.Value
+	if mtype.IsVariadic() {
+		out = m.CallSlice(in)
+	} else {
+		out = m.Call(in)
+	}
+	results := make([]any, len(out))
+	for j, v := range out {
+		results[j] = v.Interface()
+	}
+	return results
+}
+
+// intercept returns the proxy of val if svc is intercepted when it is
+// resolved as name. The proxy of a singleton is built once per instance.
+func (i *Injector) intercept(svc Service, opts *providerOptions, name string, val reflect.Value) (reflect.Value, error) {
+	iface, ok := opts.interceptedAs[name]
+	if !ok {
+		return val, nil
+	}
+	if !opts.isSingleton() {
+		return newProxy(iface, val, opts.Interceptors)
+	}
+	return svc.state().proxy(i, name, val, func() (reflect.Value, error) {
+		return newProxy(iface, val, opts.Interceptors)
+	})
+}
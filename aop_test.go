@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type aopRepo interface {
+	Get(id int) (string, error)
+	Log(format string, args ...any) string
+}
+
+type aopRepoProxy struct {
+	h ProxyHandler
+}
+
+func (p aopRepoProxy) Get(a0 int) (string, error) {
+	out := p.h("Get", a0)
+	r0, _ := out[0].(string)
+	r1, _ := out[1].(error)
+	return r0, r1
+}
+
+func (p aopRepoProxy) Log(a0 string, a1 ...any) string {
+	out := p.h("Log", a0, a1)
+	r0, _ := out[0].(string)
+	return r0
+}
+
+func init() {
+	RegisterProxy(func(h ProxyHandler) aopRepo { return aopRepoProxy{h} })
+}
+
+type aopRepoImpl struct{}
+
+func (r *aopRepoImpl) Get(id int) (string, error) {
+	return fmt.Sprint("user ", id), nil
+}
+
+func (r *aopRepoImpl) Log(format string, args ...any) string {
+	return fmt.Sprintf(format, args...)
+}
+
+type aopConsumer struct {
+	Repo aopRepo
+}
+
+func TestInjector_Interceptors(t *testing.T) {
+	i := New()
+	var calls []string
+	err := i.Provide(func() *aopRepoImpl { return &aopRepoImpl{} }, As(
+		new(aopRepo),
+		Before(func(inv Invocation) {
+			calls = append(calls, "before "+inv.Method())
+		}),
+		Around(func(inv Invocation) []any {
+			if inv.Method() == "Get" {
+				inv.Args()[0] = inv.Args()[0].(int) * 10
+			}
+			return inv.Proceed()
+		}),
+		After(func(inv Invocation, results []any) {
+			calls = append(calls, fmt.Sprint("after ", inv.Method(), " ", results[0]))
+		}),
+	))
+	assert.NoError(t, err)
+	_ = i.ProvideZero(&aopConsumer{})
+
+	c, err := i.Invoke("*wheels.aopConsumer")
+	assert.NoError(t, err)
+	repo := c.(*aopConsumer).Repo
+	user, err := repo.Get(4)
+	assert.NoError(t, err)
+	assert.Equal(t, "user 40", user)
+	assert.Equal(t, "a-b", repo.Log("%v-%v", "a", "b"))
+	assert.Equal(t, []string{"before Get", "after Get user 40", "before Log", "after Log a-b"}, calls)
+
+	for j := 0; j < 2; j++ {
+		r, err := i.Invoke("wheels.aopRepo")
+		assert.NoError(t, err)
+		assert.IsType(t, aopRepoProxy{}, r)
+		user, _ = r.(aopRepo).Get(1)
+		assert.Equal(t, "user 10", user)
+	}
+	raw, err := i.Invoke("*wheels.aopRepoImpl")
+	assert.NoError(t, err)
+	assert.IsType(t, &aopRepoImpl{}, raw)
+}
+
+func TestInjector_InterceptorsWithoutProxy(t *testing.T) {
+	i := New()
+	err := i.Provide(NewServiceA, As(new(ServiceTest), Around(func(inv Invocation) []any {
+		return inv.Proceed()
+	})))
+	assert.ErrorIs(t, err, ErrProxyNotRegistered)
+	err = i.Provide(NewServiceA, As(Around(func(inv Invocation) []any {
+		return inv.Proceed()
+	})))
+	assert.ErrorIs(t, err, ErrInvalidAsType)
+}
+
+func TestMakeProxy(t *testing.T) {
+	var calls []string
+	p, err := MakeProxy(reflect.TypeOf(new(aopRepo)).Elem(), func(method string, args ...any) []any {
+		calls = append(calls, fmt.Sprint(method, args))
+		return []any{"stub", nil}
+	})
+	assert.NoError(t, err)
+	s, err := p.(aopRepo).Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "stub", s)
+	assert.Equal(t, []string{"Get[1]"}, calls)
+
+	_, err = MakeProxy(reflect.TypeOf(new(ServiceTest)).Elem(), nil)
+	assert.ErrorIs(t, err, ErrProxyNotRegistered)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"fmt"
+)
+
+// Build instantiates every registered singleton service, so that ctor
+// failures surface at startup rather than at the first Invoke. It keeps
+// going after a failure and returns the errors of all the services that
+// could not be built. Transient and scoped services are left alone.
+func (i *Injector) Build() error {
+	return i.BuildContext(context.Background())
+}
+
+// BuildContext is like Build, but passes ctx to the ctors.
+func (i *Injector) BuildContext(ctx context.Context) error {
+	i.mu.RLock()
+	var names []string
+	for _, svc := range i.unbuiltSingletonsLocked(false) {
+		names = append(names, svc.getName())
+	}
+	i.mu.RUnlock()
+	return i.buildNames(ctx, names)
+}
+
+// eagerSnapshotLocked returns the snapshot to restore if the eager services
+// fail to build after the registry changes, nil if none is eager.
+func (i *Injector) eagerSnapshotLocked(eager bool) *Snapshot {
+	for _, opts := range i.serviceOptions {
+		eager = eager || opts.Eager
+	}
+	if !eager {
+		return nil
+	}
+	return i.snapshotLocked()
+}
+
+// buildEager builds the eager services that are not built, except the ones
+// in pending which already were not built before the registry changed: a
+// failed eager service is not retried by every Provide. If one fails, undo is
+// restored so that the change can be made again.
+func (i *Injector) buildEager(pending []Service, undo *Snapshot) error {
+	if undo == nil {
+		return nil
+	}
+	skip := map[Service]bool{}
+	for _, svc := range pending {
+		skip[svc] = true
+	}
+	i.mu.RLock()
+	var names []string
+	for _, svc := range i.unbuiltSingletonsLocked(true) {
+		if !skip[svc] {
+			names = append(names, svc.getName())
+		}
+	}
+	i.mu.RUnlock()
+	err := i.buildNames(context.Background(), names)
+	if err != nil {
+		undo.Restore()
+	}
+	return err
+}
+
+// unbuiltSingletonsLocked returns the singleton services that are not built,
+// only the eager ones if eager is set.
+func (i *Injector) unbuiltSingletonsLocked(eager bool) []Service {
+	var svcs []Service
+	for _, svc := range i.registeredServicesLocked() {
+		opts := i.serviceOptions[svc]
+		if !opts.isSingleton() || (eager && !opts.Eager) {
+			continue
+		}
+		if _, built := svc.getBuilt(); !built {
+			svcs = append(svcs, svc)
+		}
+	}
+	return svcs
+}
+
+func (i *Injector) buildNames(ctx context.Context, names []string) error {
+	var errs []error
+	for _, name := range names {
+		if _, err := i.invoke(ctx, name); err != nil {
+			errs = append(errs, fmt.Errorf("name: %v, err: %w", name, err))
+		}
+	}
+	return joinErrors(errs...)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjector_Build(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	_ = i.Provide(newServiceF)
+	_ = i.ProvideZero(&ServiceG{})
+	calls := 0
+	_ = i.Provide(func() *ServiceE {
+		calls++
+		return &ServiceE{}
+	}, Transient())
+
+	err := i.Build()
+	var errs MultiError
+	assert.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 2)
+	assert.ErrorIs(t, errs[0], ErrNewServiceF)
+	assert.ErrorIs(t, errs[1], ErrNewServiceF)
+	for _, name := range []string{"*wheels.ServiceB", "*wheels.ServiceC", "*wheels.ServiceD"} {
+		_, built := i.services[name].getBuilt()
+		assert.True(t, built, name)
+	}
+	assert.Equal(t, 0, calls)
+}
+
+func TestInjector_BuildEager(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	calls := 0
+	err := i.Provide(func(a *ServiceA, c *ServiceC) *ServiceB {
+		calls++
+		return &ServiceB{a: a, c: c}
+	}, Eager())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	err = i.ProvideZero(&ServiceG{}, Eager())
+	assert.ErrorIs(t, err, ErrUnknownService)
+	assert.Contains(t, err.Error(), "name: *wheels.ServiceG")
+
+	// the failed Provide is undone and can be made again
+	_, ok := i.services["*wheels.ServiceG"]
+	assert.False(t, ok)
+	assert.NoError(t, i.ProvideInstance(&ServiceF{}))
+	assert.NoError(t, i.ProvideZero(&ServiceG{}, Eager()))
+	_, built := i.services["*wheels.ServiceG"].getBuilt()
+	assert.True(t, built)
+
+	err = i.OverrideInstance(&ServiceA{val: 1})
+	assert.NoError(t, err)
+	_, built = i.services["*wheels.ServiceB"].getBuilt()
+	assert.True(t, built)
+	assert.Equal(t, 2, calls)
+
+	// the override fails with the eager service built from it and is undone
+	f, _ := i.Invoke("*wheels.ServiceF")
+	err = i.Override(newServiceF)
+	assert.ErrorIs(t, err, ErrNewServiceF)
+	assert.Contains(t, err.Error(), "name: *wheels.ServiceG")
+	nf, err := i.Invoke("*wheels.ServiceF")
+	assert.NoError(t, err)
+	assert.Same(t, f, nf)
+
+	err = i.Provide(newServiceJ, Eager())
+	assert.ErrorIs(t, err, ErrUnknownService)
+	_, ok = i.services["*wheels.ServiceJ"]
+	assert.False(t, ok)
+
+	err = i.Provide(NewServiceA, Name("a"), Eager(), Transient())
+	assert.ErrorIs(t, err, ErrInvalidLifetime)
+}
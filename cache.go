@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+)
+
+type typeMap map[reflect.Type]any
+
+// typedCache maps types to the built services named after them, for
+// Invoke[T] and InvokeFrom[T]. The map is never modified but replaced, so
+// lookups are wait-free.
+type typedCache struct {
+	m atomic.Pointer[typeMap]
+}
+
+func (c *typedCache) load(typ reflect.Type) (any, bool) {
+	m := c.m.Load()
+	if m == nil {
+		return nil, false
+	}
+	ins, ok := (*m)[typ]
+	return ins, ok
+}
+
+// snapshot returns the current map, to add an entry once the service is
+// built.
+func (c *typedCache) snapshot() *typeMap {
+	return c.m.Load()
+}
+
+// add adds ins to the map snap, unless the cache was cleared since snap was
+// taken, in which case ins may be stale.
+func (c *typedCache) add(snap *typeMap, typ reflect.Type, ins any) {
+	m := typeMap{}
+	if snap != nil {
+		for k, v := range *snap {
+			m[k] = v
+		}
+	}
+	m[typ] = ins
+	c.m.CompareAndSwap(snap, &m)
+}
+
+func (c *typedCache) clear() {
+	c.m.Store(&typeMap{})
+}
+
+// typeOf returns the type T, which may be an interface.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// invokeTyped invokes the service named after T. Without options, a built
+// singleton is returned from the typed cache of i.
+func invokeTyped[T any](ctx context.Context, i *Injector, opts []InvokeOption) (T, error) {
+	typ := typeOf[T]()
+	if len(opts) > 0 {
+		return InvokeNamedContext[T](ctx, i, typ.String(), opts...)
+	}
+	i.syncParents()
+	if ins, ok := i.typed.load(typ); ok {
+		if ins, ok := ins.(T); ok {
+			return ins, nil
+		}
+	}
+	snap := i.typed.snapshot()
+	ins, err := InvokeNamedContext[T](ctx, i, typ.String())
+	if err != nil {
+		return ins, err
+	}
+	// services of the parent are not cached, overriding them does not clear
+	// the cache of i. Nor are the ones invoked by a ctor, a zero service may
+	// not be built until the outer invocation returns.
+	if invokeStateFrom(ctx) != nil {
+		return ins, nil
+	}
+	if _, svcOpts, ok := i.lookup(typ.String()); ok && svcOpts.isSingleton() {
+		i.typed.add(snap, typ, ins)
+	}
+	return ins, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvokeFrom_TypedCache(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{val: 1})
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	calls := 0
+	_ = i.Provide(func() *ServiceE {
+		calls++
+		return &ServiceE{}
+	}, Transient())
+
+	b, err := InvokeFrom[ServiceTest](i)
+	assert.NoError(t, err)
+	cached, ok := i.typed.load(typeOf[ServiceTest]())
+	assert.True(t, ok)
+	assert.Same(t, b, cached)
+	nb, _ := InvokeFrom[ServiceTest](i)
+	assert.Same(t, b, nb)
+
+	_, _ = InvokeFrom[*ServiceE](i)
+	_, _ = InvokeFrom[*ServiceE](i)
+	assert.Equal(t, 2, calls)
+
+	err = i.OverrideInstance(&ServiceA{val: 2})
+	assert.NoError(t, err)
+	_, ok = i.typed.load(typeOf[ServiceTest]())
+	assert.False(t, ok)
+	nb, _ = InvokeFrom[ServiceTest](i)
+	assert.NotSame(t, b, nb)
+	assert.Equal(t, 2, nb.(*ServiceB).a.val)
+}
+
+func newBenchInjector() *Injector {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	_, _ = i.Invoke("*wheels.ServiceB")
+	return i
+}
+
+func BenchmarkInjector_Invoke(b *testing.B) {
+	i := newBenchInjector()
+	b.SetParallelism(64)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = i.Invoke("*wheels.ServiceB")
+		}
+	})
+}
+
+func BenchmarkInvokeFrom(b *testing.B) {
+	i := newBenchInjector()
+	b.SetParallelism(64)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = InvokeFrom[*ServiceB](i)
+		}
+	})
+}
+
+func BenchmarkInvokeFrom_Interface(b *testing.B) {
+	i := newBenchInjector()
+	b.SetParallelism(64)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = InvokeFrom[ServiceTest](i)
+		}
+	})
+}
+
+func BenchmarkInvokeFrom_Uncached(b *testing.B) {
+	i := newBenchInjector()
+	b.SetParallelism(64)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = InvokeFrom[*ServiceB](i, WithName("*wheels.ServiceB"))
+		}
+	})
+}
@@ -0,0 +1,245 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command wheels-proxy generates the proxy of an interface so that services
+// provided as the interface can be intercepted, e.g.
+//
+//	//go:generate go run github.com/rame2015/wheels/cmd/wheels-proxy -type Repo
+//
+// writes repo_proxy.go, which registers the proxy with wheels.RegisterProxy.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const wheelsImport = "github.com/rame2015/wheels"
+
+func main() {
+	typeName := flag.String("type", "", "name of the interface")
+	dir := flag.String("dir", ".", "directory of the package declaring the interface")
+	output := flag.String("output", "", "output file, defaults to <type>_proxy.go in dir")
+	flag.Parse()
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = filepath.Join(*dir, strings.ToLower(*typeName)+"_proxy.go")
+	}
+	src, err := generateDir(*dir, *typeName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "wheels-proxy:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "wheels-proxy:", err)
+		os.Exit(1)
+	}
+}
+
+func generateDir(dir, typeName string) ([]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		if iface := findInterface(file, typeName); iface != nil {
+			return generate(fset, file, typeName, iface)
+		}
+	}
+	return nil, fmt.Errorf("interface %v not found in %v", typeName, dir)
+}
+
+func findInterface(file *ast.File, typeName string) *ast.InterfaceType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if ts.Name.Name != typeName {
+				continue
+			}
+			if ts.TypeParams != nil {
+				return nil
+			}
+			iface, _ := ts.Type.(*ast.InterfaceType)
+			return iface
+		}
+	}
+	return nil
+}
+
+// generate returns the source of the proxy of the interface typeName
+// declared in file.
+func generate(fset *token.FileSet, file *ast.File, typeName string, iface *ast.InterfaceType) ([]byte, error) {
+	proxyName := string(unicode.ToLower(rune(typeName[0]))) + typeName[1:] + "Proxy"
+	qualifiers := map[string]bool{}
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "type %v struct {\n\th wheels.ProxyHandler\n}\n\n", proxyName)
+	for _, field := range iface.Methods.List {
+		ftype, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			return nil, errors.New("embedded interfaces are not supported")
+		}
+		collectQualifiers(ftype, qualifiers)
+		for _, name := range field.Names {
+			if err := writeMethod(&body, fset, proxyName, name.Name, ftype); err != nil {
+				return nil, err
+			}
+		}
+	}
+	fmt.Fprintf(&body, "func init() {\n\twheels.RegisterProxy(func(h wheels.ProxyHandler) %v { return %v{h} })\n}\n", typeName, proxyName)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by wheels-proxy. DO NOT EDIT.\n\npackage %v\n\nimport (\n", file.Name.Name)
+	for _, imp := range usedImports(file, qualifiers) {
+		fmt.Fprintf(&src, "\t%v\n", imp)
+	}
+	fmt.Fprintf(&src, "\t%q\n)\n\n", wheelsImport)
+	src.Write(body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+func writeMethod(w *bytes.Buffer, fset *token.FileSet, proxyName, method string, ftype *ast.FuncType) error {
+	var params, args []string
+	for _, field := range ftype.Params.List {
+		typ, err := exprString(fset, field.Type)
+		if err != nil {
+			return err
+		}
+		for range fieldNames(field) {
+			arg := "a" + strconv.Itoa(len(args))
+			args = append(args, arg)
+			params = append(params, arg+" "+typ)
+		}
+	}
+	var results, resultTypes []string
+	if ftype.Results != nil {
+		for _, field := range ftype.Results.List {
+			typ, err := exprString(fset, field.Type)
+			if err != nil {
+				return err
+			}
+			for range fieldNames(field) {
+				results = append(results, "r"+strconv.Itoa(len(results)))
+				resultTypes = append(resultTypes, typ)
+			}
+		}
+	}
+	call := fmt.Sprintf("p.h(%q", method)
+	for _, arg := range args {
+		call += ", " + arg
+	}
+	call += ")"
+	fmt.Fprintf(w, "func (p %v) %v(%v) (%v) {\n", proxyName, method, strings.Join(params, ", "), strings.Join(resultTypes, ", "))
+	if len(results) == 0 {
+		fmt.Fprintf(w, "\t%v\n}\n\n", call)
+		return nil
+	}
+	fmt.Fprintf(w, "\tout := %v\n", call)
+	for j, r := range results {
+		fmt.Fprintf(w, "\t%v, _ := out[%d].(%v)\n", r, j, resultTypes[j])
+	}
+	fmt.Fprintf(w, "\treturn %v\n}\n\n", strings.Join(results, ", "))
+	return nil
+}
+
+// fieldNames returns one entry per value declared by field, which may be
+// unnamed.
+func fieldNames(field *ast.Field) []*ast.Ident {
+	if len(field.Names) == 0 {
+		return []*ast.Ident{nil}
+	}
+	return field.Names
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) (string, error) {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func collectQualifiers(node ast.Node, qualifiers map[string]bool) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				qualifiers[id.Name] = true
+			}
+		}
+		return true
+	})
+}
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// usedImports returns the import specs of file whose package name is one of
+// qualifiers.
+func usedImports(file *ast.File, qualifiers map[string]bool) []string {
+	var imports []string
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		if importPath == wheelsImport && spec.Name == nil {
+			continue
+		}
+		name := path.Base(importPath)
+		if majorVersion.MatchString(name) {
+			name = path.Base(path.Dir(importPath))
+		}
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if !qualifiers[name] {
+			continue
+		}
+		if spec.Name != nil {
+			imports = append(imports, spec.Name.Name+" "+spec.Path.Value)
+		} else {
+			imports = append(imports, spec.Path.Value)
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
@@ -0,0 +1,89 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `package sample
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+type Repo interface {
+	Get(ctx context.Context, id int) (*http.Request, error)
+	Log(format string, args ...any) string
+	Close()
+}
+`
+
+const want = `// Code generated by wheels-proxy. DO NOT EDIT.
+
+package sample
+
+import (
+	"context"
+	"github.com/rame2015/wheels"
+	"net/http"
+)
+
+type repoProxy struct {
+	h wheels.ProxyHandler
+}
+
+func (p repoProxy) Get(a0 context.Context, a1 int) (*http.Request, error) {
+	out := p.h("Get", a0, a1)
+	r0, _ := out[0].(*http.Request)
+	r1, _ := out[1].(error)
+	return r0, r1
+}
+
+func (p repoProxy) Log(a0 string, a1 ...any) string {
+	out := p.h("Log", a0, a1)
+	r0, _ := out[0].(string)
+	return r0
+}
+
+func (p repoProxy) Close() {
+	p.h("Close")
+}
+
+func init() {
+	wheels.RegisterProxy(func(h wheels.ProxyHandler) Repo { return repoProxy{h} })
+}
+`
+
+func TestGenerateDir(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "repo.go"), []byte(source), 0o644)
+	assert.NoError(t, err)
+
+	src, err := generateDir(dir, "Repo")
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(src))
+
+	_, err = generateDir(dir, "Missing")
+	assert.Error(t, err)
+}
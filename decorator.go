@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Decorate registers fn to wrap the service named after the type of its first
+// param, e.g. func(c *Client, m *Metrics) *Client. fn receives the instance
+// built so far and its other params are injected like the ones of a ctor; it
+// returns the replacement of the same type, optionally with an error.
+// Decorators run in registration order every time the service is built,
+// including after it is overridden. Services provided As an interface or
+// with a Name cannot be decorated by another type, which fails.
+func (i *Injector) Decorate(fn any) error {
+	rv := reflect.ValueOf(fn)
+	rt := reflect.TypeOf(fn)
+	if rt == nil || rt.Kind() != reflect.Func || rt.NumIn() == 0 {
+		return fmt.Errorf("decorator: %T, err: %w", fn, ErrInvalidDecoratorType)
+	}
+	numOut := rt.NumOut()
+	if numOut == 0 || numOut > 2 || rt.Out(0) != rt.In(0) || (numOut == 2 && !rt.Out(1).Implements(errType)) {
+		return fmt.Errorf("decorator: %T, err: %w", fn, ErrInvalidDecoratorType)
+	}
+	name := rt.In(0).String()
+	defer i.notifyRebuilds()
+	i.mu.Lock()
+	if err := i.checkDecoratedLocked(fn, name, rt.In(0)); err != nil {
+		i.mu.Unlock()
+		return err
+	}
+	pending := i.unbuiltSingletonsLocked(true)
+	undo := i.eagerSnapshotLocked(false)
+	i.decorators[name] = append(i.decorators[name], rv)
+	if svc, ok := i.services[name]; ok {
+		i.resetServiceLocked(svc)
+	}
+	i.mu.Unlock()
+	return i.buildEager(pending, undo)
+}
+
+// checkDecoratedLocked fails if name is an alias of a service, or if a
+// service of type typ is named otherwise: the decorators of a service are
+// looked up by its name, they would never run.
+func (i *Injector) checkDecoratedLocked(fn any, name string, typ reflect.Type) error {
+	if svc, ok := i.services[name]; ok {
+		if svc.getName() != name {
+			return fmt.Errorf("decorator: %T, name: %v, service: %v, err: %w", fn, name, svc.getName(), ErrInvalidDecoratorType)
+		}
+		return nil
+	}
+	for _, svc := range i.registeredServicesLocked() {
+		if svc.getType() == typ {
+			return fmt.Errorf("decorator: %T, name: %v, service: %v, err: %w", fn, name, svc.getName(), ErrInvalidDecoratorType)
+		}
+	}
+	return nil
+}
+
+// hasDecorators reports whether decorators are registered for the service
+// name.
+func (i *Injector) hasDecorators(name string) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.decorators[name]) > 0
+}
+
+// decorate applies the decorators of the service name to val, it returns the
+// names of the services the decorators were called with.
+func (i *Injector) decorate(ctx context.Context, name string, val reflect.Value) (reflect.Value, []string, error) {
+	i.mu.RLock()
+	decorators := i.decorators[name]
+	i.mu.RUnlock()
+	var pnames []string
+	for _, dec := range decorators {
+		if !val.Type().AssignableTo(dec.Type().In(0)) {
+			return val, nil, fmt.Errorf("name: %v, decorator: %v, err: %w", name, dec.Type().String(), ErrInvalidDecoratorType)
+		}
+		dval, dnames, err := i.call(ctx, name, dec, val)
+		if err != nil {
+			return val, nil, err
+		}
+		val = dval
+		pnames = append(pnames, dnames...)
+	}
+	return val, pnames, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type decClient struct {
+	layers string
+}
+
+type decConsumer struct {
+	Client *decClient
+}
+
+func TestInjector_Decorate(t *testing.T) {
+	i := New()
+	_ = i.Provide(func() *decClient { return &decClient{layers: "client"} })
+	_ = i.ProvideZero(&decConsumer{})
+	_ = i.ProvideInstance(&ServiceA{val: 1})
+
+	c, err := i.Invoke("*wheels.decConsumer")
+	assert.NoError(t, err)
+	assert.Equal(t, "client", c.(*decConsumer).Client.layers)
+
+	err = i.Decorate(func(c *decClient) *decClient {
+		return &decClient{layers: c.layers + "+cache"}
+	})
+	assert.NoError(t, err)
+	err = i.Decorate(func(c *decClient, a *ServiceA) (*decClient, error) {
+		return &decClient{layers: c.layers + "+metrics" + string(rune('0'+a.val))}, nil
+	})
+	assert.NoError(t, err)
+	c, err = i.Invoke("*wheels.decConsumer")
+	assert.NoError(t, err)
+	assert.Equal(t, "client+cache+metrics1", c.(*decConsumer).Client.layers)
+
+	_ = i.OverrideInstance(&decClient{layers: "instance"})
+	c, _ = i.Invoke("*wheels.decConsumer")
+	assert.Equal(t, "instance+cache+metrics1", c.(*decConsumer).Client.layers)
+
+	_ = i.OverrideInstance(&ServiceA{val: 2})
+	c, _ = i.Invoke("*wheels.decConsumer")
+	assert.Equal(t, "instance+cache+metrics2", c.(*decConsumer).Client.layers)
+
+	_ = i.OverrideZero(&decClient{})
+	c, _ = i.Invoke("*wheels.decConsumer")
+	assert.Equal(t, "+cache+metrics2", c.(*decConsumer).Client.layers)
+
+	i = New()
+	_ = i.ProvideInstance(&decClient{layers: "instance"})
+	_ = i.ProvideZero(&decConsumer{})
+	c, _ = i.Invoke("*wheels.decConsumer")
+	assert.Equal(t, "instance", c.(*decConsumer).Client.layers)
+	dc, _ := InvokeFrom[*decClient](i)
+	assert.Equal(t, "instance", dc.layers)
+
+	err = i.Decorate(func(c *decClient) *decClient {
+		return &decClient{layers: c.layers + "+cache"}
+	})
+	assert.NoError(t, err)
+	dc, _ = InvokeFrom[*decClient](i)
+	assert.Equal(t, "instance+cache", dc.layers)
+	c, _ = i.Invoke("*wheels.decConsumer")
+	assert.Equal(t, "instance+cache", c.(*decConsumer).Client.layers)
+}
+
+func TestInjector_DecorateInvalid(t *testing.T) {
+	i := New()
+	assert.ErrorIs(t, i.Decorate(&decClient{}), ErrInvalidDecoratorType)
+	assert.ErrorIs(t, i.Decorate(func() *decClient { return nil }), ErrInvalidDecoratorType)
+	assert.ErrorIs(t, i.Decorate(func(c *decClient) *ServiceA { return nil }), ErrInvalidDecoratorType)
+}
+
+func TestInjector_DecorateAlias(t *testing.T) {
+	i := New()
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.ProvideInstance(&decClient{layers: "client"}, Name("d"))
+
+	err := i.Decorate(func(s ServiceTest) ServiceTest { return s })
+	assert.ErrorIs(t, err, ErrInvalidDecoratorType)
+	assert.EqualError(t, err, "decorator: func(wheels.ServiceTest) wheels.ServiceTest, name: wheels.ServiceTest, service: *wheels.ServiceB, err: invalid decorator type")
+	err = i.Decorate(func(c *decClient) *decClient { return c })
+	assert.ErrorIs(t, err, ErrInvalidDecoratorType)
+	assert.EqualError(t, err, "decorator: func(*wheels.decClient) *wheels.decClient, name: *wheels.decClient, service: d, err: invalid decorator type")
+	assert.NoError(t, i.Decorate(func(b *ServiceB) *ServiceB { return b }))
+}
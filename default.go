@@ -16,7 +16,9 @@ limitations under the License.
 
 package wheels
 
-import "fmt"
+import (
+	"context"
+)
 
 var defaultInjector *Injector = New()
 
@@ -48,15 +50,14 @@ func OverrideZero(val any, opts ...ProvideOption) error {
 	return Default().OverrideZero(val, opts...)
 }
 
+func Decorate(fn any) error {
+	return Default().Decorate(fn)
+}
+
 func Invoke[T any](opts ...InvokeOption) (ins T, err error) {
-	name := fmt.Sprintf("%T", ins)
-	val, err := Default().invoke(name, opts...)
-	if err != nil {
-		return
-	}
-	ins, ok := val.(T)
-	if !ok {
-		return ins, fmt.Errorf("name: %v, err: %v", name, ErrInvalidInvokeType)
-	}
-	return
+	return InvokeContext[T](context.Background(), opts...)
+}
+
+func InvokeContext[T any](ctx context.Context, opts ...InvokeOption) (ins T, err error) {
+	return invokeTyped[T](ctx, Default(), opts)
 }
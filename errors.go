@@ -1,6 +1,12 @@
 package wheels
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
 
 var (
 	ErrServiceAlreadyExists   = errors.New("service already exists")
@@ -10,4 +16,192 @@ var (
 	ErrInvalidCtorType        = errors.New("invalid ctor type")
 	ErrInvalidZeroType        = errors.New("invalid zero type")
 	ErrInvalidInvokeType      = errors.New("invalid invoke type")
+	ErrCircularDependency     = errors.New("circular dependency")
+	ErrInvalidGroupType       = errors.New("invalid group type")
+	ErrInvalidFieldType       = errors.New("invalid field type")
+	ErrInvalidLifetime        = errors.New("invalid lifetime")
+	ErrOutOfScope             = errors.New("service out of scope")
+	ErrScopeClosed            = errors.New("scope closed")
+	ErrScopeMismatch          = errors.New("scoped service injected into singleton")
+	ErrProxyNotRegistered     = errors.New("proxy not registered")
+	ErrInvalidDecoratorType   = errors.New("invalid decorator type")
+	ErrNotBuilt               = errors.New("service not built")
+	ErrConstructorPanic       = errors.New("constructor panic")
+	ErrInvalidRetry           = errors.New("invalid retry")
 )
+
+// TimeoutError is returned when the context of an invocation is done while a
+// service is being constructed. Name is the service under construction and
+// Err wraps the context error.
+type TimeoutError struct {
+	Name string
+	Err  error
+}
+
+func newTimeoutError(name string, err error) *TimeoutError {
+	return &TimeoutError{Name: name, Err: err}
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("name: %v, err: %v", e.Name, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the context deadline was exceeded rather than
+// the context being canceled.
+func (e *TimeoutError) Timeout() bool {
+	return errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// ResolutionError is returned when a service cannot be built because one of
+// its dependencies cannot be resolved. It wraps the error of the dependency.
+type ResolutionError struct {
+	// Service is the service invoked.
+	Service string
+	// Path holds the services being resolved from Service down to the
+	// dependency that failed.
+	Path []string
+	// Param is the index of the ctor or decorator param the dependency is
+	// injected into, -1 for a field of a zero service.
+	Param int
+	// Field is the struct field the dependency is injected into, in a zero
+	// service or a param object.
+	Field string
+	Err   error
+}
+
+// newResolutionError returns the error of the dependency dep of the service
+// being resolved in ctx. The error of a dependency deeper in the path is
+// returned as is.
+func newResolutionError(ctx context.Context, dep string, param int, field string, err error) error {
+	if _, ok := err.(*ResolutionError); ok {
+		return err
+	}
+	var path []string
+	for _, link := range resolvingFrom(ctx).chain() {
+		path = append(path, link.name)
+	}
+	path = append(path, dep)
+	return &ResolutionError{Service: path[0], Path: path, Param: param, Field: field, Err: err}
+}
+
+func (e *ResolutionError) Error() string {
+	at := fmt.Sprintf("param: %d", e.Param)
+	if e.Field != "" {
+		at = "field: " + e.Field
+		if e.Param >= 0 {
+			at = fmt.Sprintf("param: %d, %v", e.Param, at)
+		}
+	}
+	return fmt.Sprintf("name: %v, path: %v, %v, err: %v", e.Service, strings.Join(e.Path, " -> "), at, e.Err)
+}
+
+func (e *ResolutionError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is returned when a ctor, a decorator or the injection of a
+// service panics. It matches ErrConstructorPanic and, if the panic value is
+// an error, that error.
+type PanicError struct {
+	Name string
+	// Path holds the services being resolved from the one invoked down to
+	// Name.
+	Path  []string
+	Value any
+	Stack []byte
+}
+
+func newPanicError(ctx context.Context, name string, v any) *PanicError {
+	var path []string
+	for _, link := range resolvingFrom(ctx).chain() {
+		path = append(path, link.name)
+	}
+	return &PanicError{Name: name, Path: path, Value: v, Stack: debug.Stack()}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("name: %v, path: %v, panic: %v, err: %v", e.Name, strings.Join(e.Path, " -> "), e.Value, ErrConstructorPanic)
+}
+
+func (e *PanicError) Is(target error) bool {
+	err, ok := e.Value.(error)
+	return ok && errors.Is(err, target)
+}
+
+func (e *PanicError) Unwrap() error {
+	return ErrConstructorPanic
+}
+
+// RetryError is returned when a ctor provided with the Retry option fails
+// for the last time after it was retried, or while waiting to retry. Err is
+// the error of the last attempt, joined with the error of the context if it
+// was done while waiting to retry.
+type RetryError struct {
+	Name     string
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("name: %v, attempts: %d, err: %v", e.Name, e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError aggregates the errors of an operation that keeps going after
+// the first failure, such as Injector.Shutdown.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (m MultiError) Is(target error) bool {
+	for _, err := range m {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m MultiError) As(target any) bool {
+	for _, err := range m {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m MultiError) Unwrap() []error {
+	return m
+}
+
+// joinErrors returns nil for no errors, the error itself for a single one
+// and a MultiError otherwise.
+func joinErrors(errs ...error) error {
+	var m MultiError
+	for _, err := range errs {
+		if err != nil {
+			m = append(m, err)
+		}
+	}
+	switch len(m) {
+	case 0:
+		return nil
+	case 1:
+		return m[0]
+	}
+	return m
+}
@@ -0,0 +1,272 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type invokeStateKey struct{}
+
+// invokeState is the state of an Invoke call. An invocation builds services
+// on its own goroutine, independent invocations build in parallel.
+type invokeState struct {
+	// zero services handed out before their fields are set, built before
+	// the invocation returns
+	early []earlyService
+	// the build the invocation waits for, guarded by waitMu
+	waiting *buildCall
+}
+
+type earlyService struct {
+	injector *Injector
+	svc      Service
+}
+
+// withInvokeState returns the ctx of a new invocation, unless ctx already
+// belongs to one. root is set for a new invocation.
+func withInvokeState(ctx context.Context) (_ context.Context, inv *invokeState, root bool) {
+	if inv := invokeStateFrom(ctx); inv != nil {
+		return ctx, inv, false
+	}
+	inv = &invokeState{}
+	return context.WithValue(ctx, invokeStateKey{}, inv), inv, true
+}
+
+func invokeStateFrom(ctx context.Context) *invokeState {
+	inv, _ := ctx.Value(invokeStateKey{}).(*invokeState)
+	return inv
+}
+
+// setEarly records that the zero service svc of i was handed out early.
+func (inv *invokeState) setEarly(i *Injector, svc Service) {
+	inv.early = append(inv.early, earlyService{injector: i, svc: svc})
+}
+
+// buildEarly builds the zero services handed out early. The ones left when a
+// build fails are kept by their injector for the next invocation.
+func (inv *invokeState) buildEarly(ctx context.Context) error {
+	for len(inv.early) > 0 {
+		e := inv.early[0]
+		name := e.svc.getName()
+		opts := e.injector.optionsOf(e.svc)
+		if opts == nil {
+			// overridden meanwhile
+			inv.early = inv.early[1:]
+			continue
+		}
+		_, err := e.svc.buildValue(withResolving(ctx, name, e.svc, opts), e.injector, name)
+		if err != nil {
+			for _, e := range inv.early {
+				e.injector.mu.Lock()
+				e.injector.earlyServices[e.svc.getName()] = e.svc
+				e.injector.mu.Unlock()
+			}
+			inv.early = nil
+			return err
+		}
+		inv.early = inv.early[1:]
+	}
+	return nil
+}
+
+// recoverBuild turns a panic during the build of the service name into a
+// PanicError, so that the build is finished and can be tried again. It must
+// be deferred after the call finishing the build.
+func recoverBuild(ctx context.Context, name string, err *error) {
+	if v := recover(); v != nil {
+		*err = newPanicError(ctx, name, v)
+	}
+}
+
+// waitMu guards the builds invocations wait for. It is shared by all the
+// injectors since a child and its parent build services for each other.
+var waitMu sync.Mutex
+
+// buildCall is a build of a service in progress, the other invocations that
+// need the service wait for it to be done.
+type buildCall struct {
+	done  chan struct{}
+	owner *invokeState
+	gen   uint64
+	val   reflect.Value
+	err   error
+}
+
+func newBuildCall(ctx context.Context, gen uint64) *buildCall {
+	return &buildCall{done: make(chan struct{}), owner: invokeStateFrom(ctx), gen: gen}
+}
+
+func (c *buildCall) finish(val reflect.Value, err error) {
+	c.val, c.err = val, err
+	close(c.done)
+}
+
+// wait waits for the build of the service name. It fails instead if the
+// build waits, through other invocations, for the invocation of ctx, which
+// means the services depend on each other.
+func (c *buildCall) wait(ctx context.Context, name string) (reflect.Value, error) {
+	inv := invokeStateFrom(ctx)
+	waitMu.Lock()
+	for owner := c.owner; owner != nil; {
+		if owner == inv {
+			waitMu.Unlock()
+			var names []string
+			for _, link := range resolvingFrom(ctx).chain() {
+				names = append(names, link.name)
+			}
+			names = append(names, name)
+			return reflect.Value{}, fmt.Errorf("path: %v, err: %w, built concurrently", strings.Join(names, " -> "), ErrCircularDependency)
+		}
+		if owner.waiting == nil {
+			break
+		}
+		owner = owner.waiting.owner
+	}
+	inv.waiting = c
+	waitMu.Unlock()
+	defer func() {
+		waitMu.Lock()
+		inv.waiting = nil
+		waitMu.Unlock()
+	}()
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		return reflect.Value{}, newTimeoutError(name, ctx.Err())
+	}
+}
+
+// buildState is the state of a singleton service. At most one invocation
+// builds the service at a time, the others wait for its build.
+type buildState struct {
+	mu         sync.Mutex
+	gen        uint64 // incremented by reset, a build started before is dropped
+	call       *buildCall
+	built      bool
+	instance   any
+	value      reflect.Value
+	paramNames []string
+	proxies    map[string]reflect.Value
+}
+
+func (b *buildState) state() *buildState {
+	return b
+}
+
+func (b *buildState) getParamNames() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.paramNames
+}
+
+// begin returns the value of the service if it is built, or after waiting
+// for the build in progress. Otherwise it returns the call the invocation of
+// ctx builds the service with, and the value to build upon.
+func (b *buildState) begin(ctx context.Context, name string) (val reflect.Value, call *buildCall, err error) {
+	b.mu.Lock()
+	if b.built {
+		defer b.mu.Unlock()
+		return b.value, nil, nil
+	}
+	if c := b.call; c != nil {
+		b.mu.Unlock()
+		val, err = c.wait(ctx, name)
+		return val, nil, err
+	}
+	b.call = newBuildCall(ctx, b.gen)
+	defer b.mu.Unlock()
+	return b.value, b.call, nil
+}
+
+// finish stores the value built by call as the instance of svc unless the
+// service was reset meanwhile, and hands it to the invocations waiting.
+func (b *buildState) finish(i *Injector, svc Service, insName string, call *buildCall, val reflect.Value, pnames []string, err error) {
+	i.mu.Lock()
+	b.mu.Lock()
+	stored := err == nil && b.gen == call.gen
+	if stored {
+		b.built = true
+		b.value = val
+		b.instance = val.Interface()
+		b.paramNames = pnames
+		for _, pname := range pnames {
+			i.appendAssociatedService(pname, svc)
+		}
+		// intercepted names get their proxy instead, and names overridden
+		// meanwhile belong to another service
+		if opts := i.serviceOptions[svc]; i.services[insName] == svc {
+			if _, ok := opts.interceptedAs[insName]; !ok {
+				i.setInstance(insName, b.instance)
+			}
+		}
+	}
+	if b.call == call {
+		b.call = nil
+	}
+	b.mu.Unlock()
+	if stored {
+		for _, pname := range pnames {
+			i.appendParentDepLocked(pname, svc)
+		}
+	}
+	i.mu.Unlock()
+	call.finish(val, err)
+}
+
+// resetLocked drops the instance and the build in progress, it reports
+// whether an instance was built.
+func (b *buildState) resetLocked() bool {
+	b.gen++
+	b.call = nil
+	b.proxies = nil
+	if !b.built {
+		return false
+	}
+	b.built = false
+	b.paramNames = nil
+	return true
+}
+
+// proxy returns the proxy of val for the intercepted name, made by create.
+// The proxy of the built instance is kept and replaces it in the instances.
+func (b *buildState) proxy(i *Injector, name string, val reflect.Value, create func() (reflect.Value, error)) (reflect.Value, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if p, ok := b.proxies[name]; ok {
+		return p, nil
+	}
+	p, err := create()
+	if err != nil {
+		return val, err
+	}
+	// Values are compared by identity, val may be an early zero value or an
+	// instance replaced by a reset since
+	if b.built && b.value == val {
+		if b.proxies == nil {
+			b.proxies = map[string]reflect.Value{}
+		}
+		b.proxies[name] = p
+		i.setInstance(name, p.Interface())
+	}
+	return p, nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjector_ConcurrentBuild(t *testing.T) {
+	i := New()
+	aStarted, fStarted := make(chan struct{}), make(chan struct{})
+	// each ctor only returns once the other one runs
+	_ = i.Provide(func() (*ServiceA, error) {
+		close(aStarted)
+		select {
+		case <-fStarted:
+			return &ServiceA{}, nil
+		case <-time.After(time.Second):
+			return nil, ErrNewServiceF
+		}
+	})
+	_ = i.Provide(func() (*ServiceF, error) {
+		close(fStarted)
+		select {
+		case <-aStarted:
+			return &ServiceF{}, nil
+		case <-time.After(time.Second):
+			return nil, ErrNewServiceF
+		}
+	})
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"*wheels.ServiceA", "*wheels.ServiceF"} {
+		name := name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := i.Invoke(name)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestInjector_SingleFlight(t *testing.T) {
+	i := New()
+	var calls int32
+	release := make(chan struct{})
+	_ = i.Provide(func() *ServiceA {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return &ServiceA{}
+	})
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	_ = i.Provide(NewServiceB)
+
+	var wg sync.WaitGroup
+	results := make([]any, 8)
+	for j := range results {
+		j := j
+		name := "*wheels.ServiceA"
+		if j%2 == 1 {
+			name = "*wheels.ServiceD"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ins, err := i.Invoke(name)
+			assert.NoError(t, err)
+			if d, ok := ins.(*ServiceD); ok {
+				ins = d.A
+			}
+			results[j] = ins
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, ins := range results {
+		assert.Same(t, results[0], ins)
+	}
+	b, err := i.Invoke("*wheels.ServiceB")
+	assert.NoError(t, err)
+	assert.Same(t, results[0], b.(*ServiceB).a)
+}
+
+func TestInjector_ConcurrentCycles(t *testing.T) {
+	for n := 0; n < 20; n++ {
+		i := New()
+		_ = i.Provide(func(b *cycleB) *cycleA { return &cycleA{b: b} })
+		_ = i.Provide(func(a *cycleA) *cycleB { return &cycleB{a: a} })
+		_ = i.ProvideInstance(&ServiceA{})
+		_ = i.ProvideZero(&ServiceC{})
+		_ = i.ProvideZero(&ServiceD{})
+
+		var wg sync.WaitGroup
+		for _, name := range []string{"*wheels.cycleA", "*wheels.cycleB"} {
+			name := name
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := i.Invoke(name)
+				assert.ErrorIs(t, err, ErrCircularDependency)
+			}()
+		}
+		for _, name := range []string{"*wheels.ServiceC", "*wheels.ServiceD"} {
+			name := name
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := i.Invoke(name)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		c, _ := i.Invoke("*wheels.ServiceC")
+		d, _ := i.Invoke("*wheels.ServiceD")
+		assert.Same(t, d, c.(*ServiceC).D)
+		assert.Same(t, c, d.(*ServiceD).C)
+	}
+}
+
+type namedWrongType struct {
+	B *ServiceB `wheels:"name=a"`
+}
+
+func TestInjector_BuildPanic(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{}, Name("a"))
+	_ = i.ProvideZero(&namedWrongType{})
+
+	for j := 0; j < 2; j++ {
+		done := make(chan error)
+		go func() {
+			_, err := i.Invoke("*wheels.namedWrongType")
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			assert.Error(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("invoke blocked by the previous build")
+		}
+	}
+}
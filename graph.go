@@ -0,0 +1,247 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// NodeKind is how a service is provided.
+type NodeKind string
+
+const (
+	KindLazy     NodeKind = "lazy"     // Provide
+	KindZero     NodeKind = "zero"     // ProvideZero
+	KindInstance NodeKind = "instance" // ProvideInstance
+)
+
+// EdgeKind is how a dependency is injected.
+type EdgeKind string
+
+const (
+	EdgeCtor      EdgeKind = "ctor"      // a param of the ctor
+	EdgeField     EdgeKind = "field"     // a field of a zero service
+	EdgeDecorator EdgeKind = "decorator" // a param of a decorator
+)
+
+// GraphNode is a service registered in an injector.
+type GraphNode struct {
+	Name    string
+	Type    reflect.Type
+	Kind    NodeKind
+	Aliases []string // the names the service is provided As
+	Built   bool
+}
+
+// GraphEdge is a dependency of the service From on the service To.
+type GraphEdge struct {
+	From string
+	To   string
+	// Ref is the name the dependency is resolved by, an alias of To or the
+	// group To is a member of.
+	Ref      string
+	Group    bool
+	Optional bool
+	Kind     EdgeKind
+	// Circular is set if From is reachable from To, the cycle is resolved
+	// by handing out a zero service before its fields are set.
+	Circular bool
+}
+
+// Graph is a snapshot of the services of an injector and their dependencies.
+type Graph struct {
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+// Graph returns the services registered in i, sorted by name, and the
+// dependencies their ctor, fields and decorators declare. A built service is
+// rebuilt when the To of one of its edges is overridden.
+// Dependencies on services that are not registered in i are left out.
+func (i *Injector) Graph() *Graph {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	g := &Graph{}
+	for _, svc := range i.registeredServicesLocked() {
+		var aliases []string
+		for _, name := range i.serviceInstances[svc] {
+			if name != svc.getName() {
+				aliases = append(aliases, name)
+			}
+		}
+		_, built := svc.getBuilt()
+		g.Nodes = append(g.Nodes, GraphNode{
+			Name:    svc.getName(),
+			Type:    svc.getType(),
+			Kind:    nodeKind(svc),
+			Aliases: aliases,
+			Built:   built,
+		})
+		g.Edges = append(g.Edges, i.edgesLocked(svc)...)
+	}
+	g.markCircular()
+	return g
+}
+
+// Dependency is a dependency declared by the ctor, the fields or a
+// decorator of a service, whether it is registered or not.
+type Dependency struct {
+	// Name is the service the dependency is resolved by, or the group.
+	Name     string
+	Type     reflect.Type
+	Group    bool
+	Optional bool
+	Kind     EdgeKind
+}
+
+// Dependencies returns the dependencies of the service name in the order
+// they are resolved when it is built. The services of the parents are
+// looked up if i does not provide name.
+func (i *Injector) Dependencies(name string) ([]Dependency, error) {
+	i.mu.RLock()
+	svc, ok := i.services[name]
+	if !ok {
+		i.mu.RUnlock()
+		if i.parent != nil {
+			return i.parent.Dependencies(name)
+		}
+		return nil, fmt.Errorf("name: %v, err: %w", name, ErrUnknownService)
+	}
+	defer i.mu.RUnlock()
+	var deps []Dependency
+	for _, dep := range i.declaredDependenciesLocked(svc) {
+		deps = append(deps, Dependency{
+			Name:     dep.name,
+			Type:     dep.typ,
+			Group:    dep.group,
+			Optional: dep.optional,
+			Kind:     dep.kind,
+		})
+	}
+	return deps, nil
+}
+
+// markCircular sets Circular on the edges that are part of a cycle.
+func (g *Graph) markCircular() {
+	next := map[string][]string{}
+	for _, e := range g.Edges {
+		next[e.From] = append(next[e.From], e.To)
+	}
+	reaches := func(from, to string) bool {
+		visited := map[string]bool{}
+		stack := []string{from}
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if n == to {
+				return true
+			}
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			stack = append(stack, next[n]...)
+		}
+		return false
+	}
+	for j := range g.Edges {
+		g.Edges[j].Circular = reaches(g.Edges[j].To, g.Edges[j].From)
+	}
+}
+
+// registeredServicesLocked returns the registered services sorted by name.
+func (i *Injector) registeredServicesLocked() []Service {
+	var svcs []Service
+	for svc, names := range i.serviceInstances {
+		if len(names) > 0 {
+			svcs = append(svcs, svc)
+		}
+	}
+	sort.Slice(svcs, func(a, b int) bool {
+		return svcs[a].getName() < svcs[b].getName()
+	})
+	return svcs
+}
+
+func nodeKind(svc Service) NodeKind {
+	switch svc.(type) {
+	case *ServiceZero:
+		return KindZero
+	case *ServiceInstance:
+		return KindInstance
+	}
+	return KindLazy
+}
+
+type declaredDependency struct {
+	dependency
+	kind EdgeKind
+}
+
+// declaredDependenciesLocked returns the dependencies of svc in the order
+// they are resolved when it is built.
+func (i *Injector) declaredDependenciesLocked(svc Service) []declaredDependency {
+	var deps []declaredDependency
+	add := func(kind EdgeKind, list []dependency) {
+		for _, dep := range list {
+			deps = append(deps, declaredDependency{dependency: dep, kind: kind})
+		}
+	}
+	switch s := svc.(type) {
+	case *ServiceLazy:
+		add(EdgeCtor, i.funcDependenciesLocked(s.ctor.Type(), 0))
+	case *ServiceZero:
+		add(EdgeField, i.structDependenciesLocked(s.typ.Elem()))
+	}
+	for _, dec := range i.decorators[svc.getName()] {
+		add(EdgeDecorator, i.funcDependenciesLocked(dec.Type(), 1))
+	}
+	return deps
+}
+
+// edgesLocked returns the edges from svc to the services it depends on. The
+// declared dependencies of a built service match its paramNames, which hold
+// the names it was actually built from.
+func (i *Injector) edgesLocked(svc Service) []GraphEdge {
+	deps := i.declaredDependenciesLocked(svc)
+	pnames := svc.getParamNames()
+	var edges []GraphEdge
+	for j, dep := range deps {
+		ref := dep.key()
+		if j < len(pnames) {
+			ref = pnames[j]
+		}
+		e := GraphEdge{From: svc.getName(), Optional: dep.optional, Kind: dep.kind}
+		if group, ok := isGroupKey(ref); ok {
+			e.Ref, e.Group = group, true
+			for _, member := range i.groups[group] {
+				e.To = member.getName()
+				edges = append(edges, e)
+			}
+			continue
+		}
+		to, ok := i.services[ref]
+		if !ok {
+			continue
+		}
+		e.Ref, e.To = ref, to.getName()
+		edges = append(edges, e)
+	}
+	return edges
+}
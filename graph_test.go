@@ -0,0 +1,90 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjector_Graph(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	_ = i.Provide(newServiceJ)
+	_ = i.Decorate(func(j *ServiceJ, a *ServiceA) *ServiceJ { return j })
+
+	_, err := i.Invoke("*wheels.ServiceB")
+	assert.NoError(t, err)
+
+	g := i.Graph()
+	assert.Equal(t, []GraphNode{
+		{Name: "*wheels.ServiceA", Type: reflect.TypeOf(&ServiceA{}), Kind: KindInstance, Built: true},
+		{Name: "*wheels.ServiceB", Type: reflect.TypeOf(&ServiceB{}), Kind: KindLazy, Aliases: []string{"wheels.ServiceTest"}, Built: true},
+		{Name: "*wheels.ServiceC", Type: reflect.TypeOf(&ServiceC{}), Kind: KindZero, Built: true},
+		{Name: "*wheels.ServiceD", Type: reflect.TypeOf(&ServiceD{}), Kind: KindZero, Built: true},
+		{Name: "*wheels.ServiceJ", Type: reflect.TypeOf(&ServiceJ{}), Kind: KindLazy, Built: false},
+	}, g.Nodes)
+	assert.Equal(t, []GraphEdge{
+		{From: "*wheels.ServiceB", To: "*wheels.ServiceA", Ref: "*wheels.ServiceA", Kind: EdgeCtor},
+		{From: "*wheels.ServiceB", To: "*wheels.ServiceC", Ref: "*wheels.ServiceC", Kind: EdgeCtor},
+		{From: "*wheels.ServiceC", To: "*wheels.ServiceD", Ref: "*wheels.ServiceD", Kind: EdgeField, Circular: true},
+		{From: "*wheels.ServiceD", To: "*wheels.ServiceC", Ref: "*wheels.ServiceC", Kind: EdgeField, Circular: true},
+		{From: "*wheels.ServiceD", To: "*wheels.ServiceA", Ref: "*wheels.ServiceA", Kind: EdgeField},
+		{From: "*wheels.ServiceJ", To: "*wheels.ServiceB", Ref: "wheels.ServiceTest", Kind: EdgeCtor},
+		{From: "*wheels.ServiceJ", To: "*wheels.ServiceA", Ref: "*wheels.ServiceA", Kind: EdgeDecorator},
+	}, g.Edges)
+}
+
+func TestInjector_GraphGroup(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{}, Group("members"))
+	_ = i.ProvideZero(&ServiceC{}, Group("members"))
+	_ = i.ProvideZero(&groupZero{})
+
+	g := i.Graph()
+	assert.Equal(t, []GraphEdge{
+		{From: "*wheels.groupZero", To: "*wheels.ServiceA", Ref: "members", Group: true, Kind: EdgeField},
+		{From: "*wheels.groupZero", To: "*wheels.ServiceC", Ref: "members", Group: true, Kind: EdgeField},
+	}, g.Edges)
+}
+
+func TestInjector_Dependencies(t *testing.T) {
+	i := New()
+	_ = i.Provide(newServiceJ)
+	_ = i.ProvideZero(&groupZero{})
+	_ = i.Decorate(func(j *ServiceJ, a Optional[*ServiceA]) *ServiceJ { return j })
+	c := i.Child()
+
+	deps, err := c.Dependencies("*wheels.ServiceJ")
+	assert.NoError(t, err)
+	assert.Equal(t, []Dependency{
+		{Name: "wheels.ServiceTest", Type: reflect.TypeOf(new(ServiceTest)).Elem(), Kind: EdgeCtor},
+		{Name: "*wheels.ServiceA", Type: reflect.TypeOf(&ServiceA{}), Optional: true, Kind: EdgeDecorator},
+	}, deps)
+	deps, err = i.Dependencies("*wheels.groupZero")
+	assert.NoError(t, err)
+	assert.Equal(t, "members", deps[0].Name)
+	assert.True(t, deps[0].Group)
+
+	_, err = c.Dependencies("*wheels.ServiceA")
+	assert.ErrorIs(t, err, ErrUnknownService)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+const groupPrefix = "group:"
+
+// groupKey returns the name the consumers of a group are associated with.
+func groupKey(group string) string {
+	return groupPrefix + group
+}
+
+// isGroupKey returns the group of a name created by groupKey.
+func isGroupKey(name string) (string, bool) {
+	if !strings.HasPrefix(name, groupPrefix) {
+		return "", false
+	}
+	return name[len(groupPrefix):], true
+}
+
+// getGroupValue builds a slice of type typ holding every member of group in
+// registration order.
+func (i *Injector) getGroupValue(ctx context.Context, group string, typ reflect.Type) (val reflect.Value, err error) {
+	if typ.Kind() != reflect.Slice {
+		return val, fmt.Errorf("group: %v, type: %v, err: %w", group, typ.String(), ErrInvalidGroupType)
+	}
+	i.mu.RLock()
+	members := i.groupMembersLocked(group)
+	i.mu.RUnlock()
+	val = reflect.MakeSlice(typ, 0, len(members))
+	for _, m := range members {
+		mval, err := m.owner.getServiceValue(ctx, m.svc.getName(), m.svc, m.opts)
+		if err != nil {
+			return val, err
+		}
+		if !mval.Type().AssignableTo(typ.Elem()) {
+			return val, fmt.Errorf("group: %v, name: %v, err: %w", group, m.svc.getName(), ErrInvalidGroupType)
+		}
+		val = reflect.Append(val, mval)
+	}
+	return val, nil
+}
+
+type groupMember struct {
+	owner *Injector
+	svc   Service
+	opts  *providerOptions
+}
+
+// groupMembersLocked returns the members of group provided by the parents of i
+// and not shadowed by i, followed by the members provided by i.
+func (i *Injector) groupMembersLocked(group string) []groupMember {
+	var members []groupMember
+	if i.parent != nil {
+		i.parent.mu.RLock()
+		for _, m := range i.parent.groupMembersLocked(group) {
+			if _, ok := i.services[m.svc.getName()]; !ok {
+				members = append(members, m)
+			}
+		}
+		i.parent.mu.RUnlock()
+	}
+	for _, svc := range i.groups[group] {
+		members = append(members, groupMember{owner: i, svc: svc, opts: i.serviceOptions[svc]})
+	}
+	return members
+}
+
+// updateGroupsLocked adds svc to its groups. An overridden oldSvc keeps its
+// position in the groups svc is still a member of and leaves the others.
+func (i *Injector) updateGroupsLocked(svc, oldSvc Service, groups []string) {
+	changed := map[string]bool{}
+	if oldSvc != nil {
+		for group, members := range i.groups {
+			idx := slices.IndexFunc(members, func(s Service) bool { return s == oldSvc })
+			if idx < 0 {
+				continue
+			}
+			if slices.Contains(groups, group) {
+				members[idx] = svc
+			} else {
+				i.groups[group] = slices.Delete(members, idx, idx+1)
+			}
+			changed[group] = true
+		}
+	}
+	for _, group := range groups {
+		if slices.IndexFunc(i.groups[group], func(s Service) bool { return s == svc }) < 0 {
+			i.groups[group] = append(i.groups[group], svc)
+			changed[group] = true
+		}
+	}
+	for group := range changed {
+		i.resetAssociatedService(groupKey(group))
+	}
+}
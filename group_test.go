@@ -0,0 +1,109 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type groupZero struct {
+	Members []ServiceTest `wheels:"group=members"`
+}
+
+type groupLazy struct {
+	members []ServiceTest
+}
+
+func printAll(members []ServiceTest) (out string) {
+	for _, m := range members {
+		out += m.Print()
+	}
+	return
+}
+
+func TestInjector_Group(t *testing.T) {
+	i := New()
+	_ = i.Provide(NewServiceA, Name("a"), Group("members"), Group("wheels.ServiceTest"))
+	_ = i.ProvideZero(&ServiceC{}, Group("members"), Group("wheels.ServiceTest"))
+	_ = i.ProvideZero(&ServiceD{})
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.ProvideZero(&groupZero{})
+	_ = i.Provide(func(members []ServiceTest) *groupLazy {
+		return &groupLazy{members: members}
+	})
+
+	z, err := i.Invoke("*wheels.groupZero")
+	assert.NoError(t, err)
+	assert.Equal(t, "AC", printAll(z.(*groupZero).Members))
+	l, err := i.Invoke("*wheels.groupLazy")
+	assert.NoError(t, err)
+	assert.Equal(t, "AC", printAll(l.(*groupLazy).members))
+
+	err = i.ProvideInstance(&ServiceE{}, Group("members"), Group("wheels.ServiceTest"))
+	assert.NoError(t, err)
+	z, _ = i.Invoke("*wheels.groupZero")
+	assert.Equal(t, "ACE", printAll(z.(*groupZero).Members))
+	l, _ = i.Invoke("*wheels.groupLazy")
+	assert.Equal(t, "ACE", printAll(l.(*groupLazy).members))
+
+	err = i.OverrideInstance(&ServiceB{}, Name("a"), Group("members"))
+	assert.NoError(t, err)
+	z, _ = i.Invoke("*wheels.groupZero")
+	assert.Equal(t, "BCE", printAll(z.(*groupZero).Members))
+	l, _ = i.Invoke("*wheels.groupLazy")
+	assert.Equal(t, "CE", printAll(l.(*groupLazy).members))
+}
+
+func TestInjector_GroupInvalidType(t *testing.T) {
+	i := New()
+	_ = i.Provide(NewServiceA, Group("members"))
+	_ = i.Provide(func(members []*ServiceB) *groupLazy { return nil })
+	_ = i.Provide(func(p struct {
+		In
+		Members *ServiceB `wheels:"group=members"`
+	}) *ServiceB {
+		return nil
+	})
+
+	// an untagged slice is a group only if the group has members
+	_, err := i.Invoke("*wheels.groupLazy")
+	assert.ErrorIs(t, err, ErrUnknownService)
+	_ = i.ProvideInstance(&ServiceB{}, Name("b"), Group("*wheels.ServiceB"))
+	_, err = i.Invoke("*wheels.groupLazy")
+	assert.NoError(t, err)
+	_, err = i.Invoke("*wheels.ServiceB")
+	assert.ErrorIs(t, err, ErrInvalidGroupType)
+}
+
+func TestInjector_ChildGroup(t *testing.T) {
+	parent := New()
+	_ = parent.Provide(NewServiceA, Name("a"), Group("members"))
+	_ = parent.ProvideInstance(&ServiceB{}, Name("b"), Group("members"))
+	child := parent.Child()
+	_ = child.ProvideInstance(&ServiceE{}, Name("b"), Group("members"))
+	_ = child.ProvideZero(&groupZero{})
+
+	z, err := child.Invoke("*wheels.groupZero")
+	assert.NoError(t, err)
+	assert.Equal(t, "AE", printAll(z.(*groupZero).Members))
+
+	_ = parent.OverrideInstance(&ServiceC{}, Name("a"), Group("members"))
+	z, _ = child.Invoke("*wheels.groupZero")
+	assert.Equal(t, "CE", printAll(z.(*groupZero).Members))
+}
@@ -0,0 +1,72 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// Handle always points at the current instance of a service, it is swapped
+// when an override rebuilds the service.
+type Handle[T any] struct {
+	ptr    atomic.Pointer[T]
+	cancel func()
+}
+
+// Get returns the current instance.
+func (h *Handle[T]) Get() T {
+	return *h.ptr.Load()
+}
+
+// Close stops following the rebuilds of the service.
+func (h *Handle[T]) Close() {
+	h.cancel()
+}
+
+// InvokeHandle is like Invoke, but returns a handle that follows the overrides
+// of the service.
+func InvokeHandle[T any](opts ...InvokeOption) (*Handle[T], error) {
+	return invokeHandle[T](Default(), opts...)
+}
+
+func invokeHandle[T any](i *Injector, opts ...InvokeOption) (*Handle[T], error) {
+	name := typeName[T]()
+	if options := newInvokeOptions(opts); options.Name != "" {
+		name = options.Name
+	}
+	h := &Handle[T]{}
+	h.cancel = i.OnRebuild(name, func(old, new any) {
+		if ins, ok := new.(T); ok {
+			h.ptr.Store(&ins)
+		}
+	})
+	val, err := i.invoke(context.Background(), name, opts...)
+	if err != nil {
+		h.cancel()
+		return nil, err
+	}
+	ins, ok := val.(T)
+	if !ok {
+		h.cancel()
+		return nil, fmt.Errorf("name: %v, err: %w", name, ErrInvalidInvokeType)
+	}
+	// a rebuild that happened meanwhile is newer
+	h.ptr.CompareAndSwap(nil, &ins)
+	return h, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvokeHandle(t *testing.T) {
+	_ = OverrideInstance(&ServiceA{val: 1})
+	h, err := InvokeHandle[*ServiceA]()
+	assert.NoError(t, err)
+	defer h.Close()
+	assert.Equal(t, 1, h.Get().val)
+
+	err = OverrideInstance(&ServiceA{val: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, h.Get().val)
+}
+
+func TestInvokeHandle_Interface(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+
+	h, err := invokeHandle[ServiceTest](i)
+	assert.NoError(t, err)
+	assert.Equal(t, "B", h.Get().Print())
+
+	err = i.Override(NewServiceA, As(new(ServiceTest)))
+	assert.NoError(t, err)
+	assert.Equal(t, "A", h.Get().Print())
+
+	h.Close()
+	err = i.Override(NewServiceB, As(new(ServiceTest)))
+	assert.NoError(t, err)
+	assert.Equal(t, "A", h.Get().Print())
+
+	_, err = invokeHandle[*ServiceE](i)
+	assert.ErrorIs(t, err, ErrUnknownService)
+}
@@ -17,21 +17,42 @@ limitations under the License.
 package wheels
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/exp/slices"
 )
 
 type Injector struct {
+	parent    *Injector
 	instances sync.Map
+	typed     typedCache
 
+	// mu guards the registry, services are built without holding it
 	mu                 sync.RWMutex
 	services           map[string]Service
 	serviceInstances   map[Service][]string
 	earlyServices      map[string]Service
 	associatedServices map[string][]Service
+	serviceOptions     map[Service]*providerOptions
+	groups             map[string][]Service
+	decorators         map[string][]reflect.Value
+	watchers           map[string][]*watcher
+	rebuilds           []rebuild
+	// gens counts the resets of each name, resets counts them all: the
+	// children of i check them, i does not keep track of its children
+	gens   map[string]uint64
+	resets atomic.Uint64
+	// parentDeps holds the services of the parents each service of i was
+	// built from, synced is the sum of the resets of the parents checked
+	parentDeps map[Service][]parentDep
+	synced     atomic.Uint64
+
+	started []*lifecycleEntry
+	running map[Service]*lifecycleEntry
 }
 
 func New() *Injector {
@@ -40,9 +61,30 @@ func New() *Injector {
 		serviceInstances:   map[Service][]string{},
 		earlyServices:      map[string]Service{},
 		associatedServices: map[string][]Service{},
+		serviceOptions:     map[Service]*providerOptions{},
+		groups:             map[string][]Service{},
+		decorators:         map[string][]reflect.Value{},
+		watchers:           map[string][]*watcher{},
+		gens:               map[string]uint64{},
+		parentDeps:         map[Service][]parentDep{},
+		running:            map[Service]*lifecycleEntry{},
 	}
 }
 
+// Child returns an injector that resolves the services it does not provide
+// from i. Provide and Override on the child shadow the services of i only
+// inside the child. A service provided by i is always built and cached by i
+// from the services of i: resolved through the child, it is not built from
+// the services the child shadows, provide it in the child as well for that.
+// Overriding a service of i resets the services of the child built from it,
+// the next time the child resolves a service. i keeps no reference to the
+// child, which is collected once discarded.
+func (i *Injector) Child() *Injector {
+	c := New()
+	c.parent = i
+	return c
+}
+
 func (i *Injector) Provide(ctor any, opts ...ProvideOption) error {
 	options := &providerOptions{}
 	for _, po := range opts {
@@ -77,11 +119,24 @@ func (i *Injector) ProvideZero(val any, opts ...ProvideOption) error {
 }
 
 func (i *Injector) Invoke(name string, opts ...InvokeOption) (ins any, err error) {
-	ins, ok := i.getInstance(name)
-	if ok {
-		return
+	return i.InvokeContext(context.Background(), name, opts...)
+}
+
+// InvokeContext is like Invoke, but passes ctx to the ctors that declare a
+// context.Context as first param and stops building once ctx is done.
+func (i *Injector) InvokeContext(ctx context.Context, name string, opts ...InvokeOption) (ins any, err error) {
+	options := newInvokeOptions(opts)
+	if options.Name != "" {
+		name = options.Name
+	}
+	i.syncParents()
+	if !options.Fresh {
+		ins, ok := i.getInstance(name)
+		if ok {
+			return ins, nil
+		}
 	}
-	return i.invoke(name)
+	return i.invoke(ctx, name, opts...)
 }
 
 func (i *Injector) Override(ctor any, opts ...ProvideOption) error {
@@ -118,32 +173,53 @@ func (i *Injector) OverrideZero(val any, opts ...ProvideOption) error {
 }
 
 func (i *Injector) provide(svc Service, opts *providerOptions) (err error) {
+	defer i.notifyRebuilds()
 	i.mu.Lock()
-	defer i.mu.Unlock()
-	return i.provideLocked(svc, opts)
+	pending := i.unbuiltSingletonsLocked(true)
+	undo := i.eagerSnapshotLocked(opts.Eager)
+	err = i.provideLocked(svc, opts)
+	i.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return i.buildEager(pending, undo)
 }
 
 func (i *Injector) override(svc Service, opts *providerOptions) (err error) {
+	defer i.notifyRebuilds()
 	i.mu.Lock()
-	defer i.mu.Unlock()
+	pending := i.unbuiltSingletonsLocked(true)
+	undo := i.eagerSnapshotLocked(opts.Eager)
 	opts.IsOverride = true
 	err = i.provideLocked(svc, opts)
+	i.mu.Unlock()
 	if err != nil {
 		return err
 	}
-	return nil
+	return i.buildEager(pending, undo)
 }
 
 func (i *Injector) provideLocked(svc Service, opts *providerOptions) (err error) {
 	name := svc.getName()
+	if _, ok := svc.(*ServiceInstance); (ok && !opts.isSingleton()) || (opts.Transient && opts.Scope != "") || (opts.Eager && !opts.isSingleton()) {
+		return fmt.Errorf("name: %v, err: %w", name, ErrInvalidLifetime)
+	}
+	if _, ok := svc.(*ServiceLazy); opts.Retry != nil && (!ok || opts.Retry.Attempts < 1) {
+		return fmt.Errorf("name: %v, err: %w", name, ErrInvalidRetry)
+	}
 	oldSvc, ok := i.services[name]
+	var replaced []Service
 	if !opts.IsOverride && ok {
 		return fmt.Errorf("name: %v, err: %w", name, ErrServiceAlreadyExists)
 	}
 	if opts.IsOverride && ok {
-		i.instances.Delete(name)
+		if old, built := oldSvc.getBuilt(); built {
+			i.recordRebuildLocked(name, old)
+		}
+		i.deleteInstance(name)
 		i.resetAssociatedService(name)
 		i.serviceInstances[oldSvc] = slices.DeleteFunc(i.serviceInstances[oldSvc], func(s string) bool { return s == name })
+		replaced = append(replaced, oldSvc)
 	}
 	insNames := []string{name}
 	for _, as := range opts.As {
@@ -165,52 +241,252 @@ func (i *Injector) provideLocked(svc Service, opts *providerOptions) (err error)
 			return fmt.Errorf("name: %v, err: %w", asName, ErrServiceAlreadyExists)
 		}
 		if opts.IsOverride {
-			i.instances.Delete(asName)
+			if ok {
+				if old, built := oldAs.getBuilt(); built {
+					i.recordRebuildLocked(asName, old)
+				}
+				replaced = append(replaced, oldAs)
+			}
+			i.deleteInstance(asName)
 			i.resetAssociatedService(asName)
 			i.serviceInstances[oldAs] = slices.DeleteFunc(i.serviceInstances[oldAs], func(s string) bool { return s == asName })
 		}
 		insNames = append(insNames, asName)
+		if len(opts.Interceptors) > 0 {
+			if _, ok := lookupProxyFactory(asrv.Type()); !ok {
+				return fmt.Errorf("as: %v, err: %w", asName, ErrProxyNotRegistered)
+			}
+			if opts.interceptedAs == nil {
+				opts.interceptedAs = map[string]reflect.Type{}
+			}
+			opts.interceptedAs[asName] = asrv.Type()
+		}
+	}
+	if len(opts.Interceptors) > 0 && len(opts.interceptedAs) == 0 {
+		return fmt.Errorf("name: %v, err: %w", name, ErrInvalidAsType)
 	}
 	i.serviceInstances[svc] = insNames
+	i.serviceOptions[svc] = opts
 	for _, v := range insNames {
 		i.services[v] = svc
+		// services that went without an optional dependency, or that got
+		// it from the parent, pick it up
+		i.resetAssociatedService(v)
+	}
+	if !opts.IsOverride {
+		oldSvc = nil
+	}
+	i.updateGroupsLocked(svc, oldSvc, opts.Groups)
+	// the services replaced under all their names are released
+	for _, old := range replaced {
+		if names, ok := i.serviceInstances[old]; ok && len(names) == 0 {
+			delete(i.serviceInstances, old)
+			delete(i.serviceOptions, old)
+		}
 	}
 	return
 }
 
-func (i *Injector) invoke(name string, opts ...InvokeOption) (ins any, err error) {
-	options := &invokeOptions{}
-	for _, io := range opts {
-		io(options)
+func (i *Injector) invoke(ctx context.Context, name string, opts ...InvokeOption) (ins any, err error) {
+	options := newInvokeOptions(opts)
+	if options.Name != "" {
+		name = options.Name
+	}
+	i.syncParents()
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+	var (
+		svc     Service
+		svcOpts *providerOptions
+		ok      bool
+	)
+	if options.Fresh {
+		defer i.notifyRebuilds()
+		svc, svcOpts, ok = i.drop(ctx, name)
+	} else {
+		svc, svcOpts, ok = i.lookup(name)
 	}
-	i.mu.Lock()
-	defer i.mu.Unlock()
-	svc, ok := i.services[name]
 	if !ok {
+		if i.parent != nil {
+			return i.parent.invoke(ctx, name, opts...)
+		}
 		return nil, fmt.Errorf("name: %v, err: %w", name, ErrUnknownService)
 	}
-	ins, err = svc.getInstance(i, name)
+	if options.WithoutBuild && !i.isBuilt(ctx, svc, svcOpts) {
+		return nil, fmt.Errorf("name: %v, err: %w", name, ErrNotBuilt)
+	}
+	ctx, inv, root := withInvokeState(ctx)
+	if root {
+		i.takeEarlyServices(inv)
+	}
+	var val reflect.Value
+	if svcOpts.isSingleton() {
+		val, err = svc.buildValue(withResolving(ctx, name, svc, svcOpts), i, name)
+		if err == nil {
+			val, err = i.intercept(svc, svcOpts, name, val)
+		}
+	} else {
+		val, err = i.getServiceValue(ctx, name, svc, svcOpts)
+	}
 	if err != nil {
 		return nil, err
 	}
-	for len(i.earlyServices) > 0 {
-		for k, s := range i.earlyServices {
-			_, err = s.getInstance(i, s.getName())
-			if err != nil {
-				return nil, err
-			}
-			delete(i.earlyServices, k)
+	if root {
+		if err = inv.buildEarly(ctx); err != nil {
+			return nil, err
 		}
 	}
-	return
+	return val.Interface(), nil
+}
+
+// isBuilt reports whether svc has a value cached for the invocation.
+func (i *Injector) isBuilt(ctx context.Context, svc Service, opts *providerOptions) bool {
+	switch {
+	case opts.Transient:
+		return false
+	case opts.Scope != "":
+		sc := scopeFrom(ctx)
+		if sc == nil || sc.name != opts.Scope {
+			return false
+		}
+		return sc.has(svc)
+	}
+	_, built := svc.getBuilt()
+	return built
+}
+
+// drop is like lookup, but also drops the value of the service cached for
+// the invocation. The services built from a singleton are reset as well.
+func (i *Injector) drop(ctx context.Context, name string) (Service, *providerOptions, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	svc, ok := i.services[name]
+	if !ok {
+		return nil, nil, false
+	}
+	opts := i.serviceOptions[svc]
+	switch {
+	case opts.Transient:
+	case opts.Scope != "":
+		if sc := scopeFrom(ctx); sc != nil && sc.name == opts.Scope {
+			sc.drop(svc)
+		}
+	default:
+		i.resetServiceLocked(svc)
+	}
+	return svc, opts, true
 }
 
-func (i *Injector) getValueLocked(name string) (val reflect.Value, err error) {
+// takeEarlyServices hands the zero services left unbuilt by a failed
+// invocation to inv.
+func (i *Injector) takeEarlyServices(inv *invokeState) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for k, svc := range i.earlyServices {
+		inv.setEarly(i, svc)
+		delete(i.earlyServices, k)
+	}
+}
+
+// lookup returns the service registered as name in i and its options.
+func (i *Injector) lookup(name string) (Service, *providerOptions, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	svc, ok := i.services[name]
+	return svc, i.serviceOptions[svc], ok
+}
+
+func (i *Injector) optionsOf(svc Service) *providerOptions {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.serviceOptions[svc]
+}
+
+// getValue resolves the service name, from the parents of i if it is not
+// registered in i.
+func (i *Injector) getValue(ctx context.Context, name string) (val reflect.Value, err error) {
+	svc, opts, ok := i.lookup(name)
 	if !ok {
+		if i.parent != nil {
+			return i.parent.getValue(ctx, name)
+		}
 		return val, fmt.Errorf("name: %v, err: %w", name, ErrUnknownService)
 	}
-	return svc.getValue(i, name)
+	return i.getServiceValue(ctx, name, svc, opts)
+}
+
+// getServiceValue returns the value of svc resolved as name. opts are looked
+// up along with svc, an override may release them meanwhile.
+func (i *Injector) getServiceValue(ctx context.Context, name string, svc Service, opts *providerOptions) (val reflect.Value, err error) {
+	val, err = i.resolveService(ctx, name, svc, opts)
+	if err != nil {
+		return val, err
+	}
+	return i.intercept(svc, opts, name, val)
+}
+
+func (i *Injector) resolveService(ctx context.Context, name string, svc Service, opts *providerOptions) (val reflect.Value, err error) {
+	r := resolvingFrom(ctx)
+	if from := r.find(svc); from != nil {
+		// a zero service is allocated before its fields are set, so it can
+		// be handed out while it is being built
+		if z, ok := svc.(*ServiceZero); ok && opts.isSingleton() {
+			return z.allocated(), nil
+		}
+		return val, newCircularDependencyError(r, from, name)
+	}
+	ctx = withResolving(ctx, name, svc, opts)
+	switch {
+	case opts.Transient:
+		return svc.newValue(ctx, i, name)
+	case opts.Scope != "":
+		return i.getScopedValue(ctx, name, svc, opts)
+	}
+	return svc.getValue(ctx, i, name)
+}
+
+// getOptionalValue is like getValue, but ok is false instead of an error if
+// the service is not registered.
+func (i *Injector) getOptionalValue(ctx context.Context, name string) (val reflect.Value, ok bool, err error) {
+	if !i.hasService(name) {
+		return val, false, nil
+	}
+	val, err = i.getValue(ctx, name)
+	return val, err == nil, err
+}
+
+// hasService reports whether name is provided by i or its parents.
+func (i *Injector) hasService(name string) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.hasServiceLocked(name)
+}
+
+func (i *Injector) hasServiceLocked(name string) bool {
+	if _, ok := i.services[name]; ok {
+		return true
+	}
+	if i.parent == nil {
+		return false
+	}
+	return i.parent.hasService(name)
+}
+
+// hasGroupLocked reports whether group has members provided by i or its
+// parents.
+func (i *Injector) hasGroupLocked(group string) bool {
+	if len(i.groups[group]) > 0 {
+		return true
+	}
+	if i.parent == nil {
+		return false
+	}
+	i.parent.mu.RLock()
+	defer i.parent.mu.RUnlock()
+	return i.parent.hasGroupLocked(group)
 }
 
 func (i *Injector) getInstance(name string) (any, bool) {
@@ -221,8 +497,9 @@ func (i *Injector) setInstance(name string, ins any) {
 	i.instances.Store(name, ins)
 }
 
-func (i *Injector) setEarlyService(svc Service) {
-	i.earlyServices[svc.getName()] = svc
+func (i *Injector) deleteInstance(name string) {
+	i.instances.Delete(name)
+	i.typed.clear()
 }
 
 func (i *Injector) appendAssociatedService(paramName string, svc Service) {
@@ -233,15 +510,113 @@ func (i *Injector) resetAssociatedService(name string) {
 	svcs := i.associatedServices[name]
 	delete(i.associatedServices, name)
 	for _, s := range svcs {
-		isReset := s.reset()
-		if !isReset {
-			continue
+		i.resetServiceLocked(s)
+	}
+	i.gens[name]++
+	i.resets.Add(1)
+}
+
+// parentDep is a service of a parent some service of a child was built from,
+// gen is the count of its resets then.
+type parentDep struct {
+	injector *Injector
+	name     string
+	gen      uint64
+}
+
+// appendParentDepLocked records the parents of i svc got pname from, along
+// with the ones that may shadow it later. Every parent is recorded for a
+// group, its members may be provided by any of them.
+func (i *Injector) appendParentDepLocked(pname string, svc Service) {
+	_, group := isGroupKey(pname)
+	if _, ok := i.services[pname]; ok && !group {
+		return
+	}
+	for p := i.parent; p != nil; p = p.parent {
+		p.mu.RLock()
+		_, ok := p.services[pname]
+		i.parentDeps[svc] = append(i.parentDeps[svc], parentDep{injector: p, name: pname, gen: p.gens[pname]})
+		p.mu.RUnlock()
+		if ok && !group {
+			return
+		}
+	}
+}
+
+// parentResets returns the sum of the resets of the parents of i.
+func (i *Injector) parentResets() uint64 {
+	var n uint64
+	for p := i.parent; p != nil; p = p.parent {
+		n += p.resets.Load()
+	}
+	return n
+}
+
+// syncParents resets the services of i built from services its parents
+// reset since the last sync, before i resolves a service.
+func (i *Injector) syncParents() {
+	if i.parent == nil {
+		return
+	}
+	i.parent.syncParents()
+	resets := i.parentResets()
+	if i.synced.Load() == resets {
+		return
+	}
+	i.mu.Lock()
+	for svc, deps := range i.parentDeps {
+		for _, d := range deps {
+			d.injector.mu.RLock()
+			stale := d.injector.gens[d.name] != d.gen
+			d.injector.mu.RUnlock()
+			if stale {
+				i.resetServiceLocked(svc)
+				break
+			}
 		}
+	}
+	i.synced.Store(resets)
+	i.mu.Unlock()
+	i.notifyRebuilds()
+}
+
+// resetServiceLocked drops the instance built by s and resets the services
+// built from it.
+func (i *Injector) resetServiceLocked(s Service) {
+	delete(i.parentDeps, s)
+	old, built := s.getBuilt()
+	isReset := s.reset()
+	if !isReset {
+		return
+	}
+	if built {
 		for _, insName := range i.serviceInstances[s] {
-			i.instances.Delete(insName)
-			i.resetAssociatedService(insName)
+			i.recordRebuildLocked(insName, old)
+		}
+	}
+	delete(i.running, s)
+	if opts := i.serviceOptions[s]; opts != nil {
+		for _, group := range opts.Groups {
+			i.resetAssociatedService(groupKey(group))
+		}
+	}
+	for _, insName := range i.serviceInstances[s] {
+		i.deleteInstance(insName)
+		i.resetAssociatedService(insName)
+	}
+}
+
+// dependenciesLocked returns the registered services svc was built from.
+func (i *Injector) dependenciesLocked(svc Service) []Service {
+	var deps []Service
+	for _, pname := range svc.getParamNames() {
+		if group, ok := isGroupKey(pname); ok {
+			deps = append(deps, i.groups[group]...)
+		} else if dep, ok := i.services[pname]; ok {
+			deps = append(deps, dep)
 		}
 	}
+	return deps
 }
 
 func checkAsType(svc, as reflect.Type) error {
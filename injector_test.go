@@ -17,8 +17,12 @@ limitations under the License.
 package wheels
 
 import (
+	"context"
 	"errors"
+	"runtime"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -454,6 +458,25 @@ func TestInjector_Override(t *testing.T) {
 	}
 }
 
+func TestInjector_OverrideReleases(t *testing.T) {
+	i := New()
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.ProvideInstance(&ServiceA{})
+	for j := 0; j < 3; j++ {
+		_ = i.OverrideInstance(&ServiceA{val: j})
+	}
+	assert.Len(t, i.serviceOptions, 2)
+	assert.Len(t, i.serviceInstances, 2)
+
+	// still registered as wheels.ServiceTest
+	_ = i.Provide(NewServiceB)
+	_ = i.Override(NewServiceB)
+	assert.Len(t, i.serviceOptions, 3)
+	_ = i.OverrideInstance(&ServiceB{}, As(new(ServiceTest)))
+	assert.Len(t, i.serviceOptions, 2)
+	assert.Len(t, i.serviceInstances, 2)
+}
+
 func TestInjector_OverrideAssociated(t *testing.T) {
 	i := New()
 	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
@@ -517,3 +540,258 @@ func TestInjector_OverrideAssociated(t *testing.T) {
 		})
 	}
 }
+
+type ctxKey struct{}
+
+type ServiceCtx struct {
+	val any
+}
+
+func TestInjector_InvokeContext(t *testing.T) {
+	i := New()
+	_ = i.Provide(func(ctx context.Context) *ServiceCtx {
+		return &ServiceCtx{val: ctx.Value(ctxKey{})}
+	})
+	_ = i.Provide(func(ctx context.Context, a *ServiceA) (*ServiceB, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	_ = i.Provide(NewServiceA)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	ins, err := i.InvokeContext(ctx, "*wheels.ServiceCtx")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", ins.(*ServiceCtx).val)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = i.InvokeContext(ctx, "*wheels.ServiceB")
+	var te *TimeoutError
+	assert.ErrorAs(t, err, &te)
+	assert.Equal(t, "*wheels.ServiceB", te.Name)
+	assert.True(t, te.Timeout())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = i.InvokeContext(ctx, "*wheels.ServiceB")
+	assert.ErrorAs(t, err, &te)
+	assert.Equal(t, "*wheels.ServiceB", te.Name)
+}
+
+func TestInjector_InvokeOptions(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.Provide(newServiceJ)
+	_ = i.Provide(NewServiceA, Name("a"))
+	_ = i.Provide(func(ctx context.Context) (*ServiceF, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	_, err := i.Invoke("*wheels.ServiceB", WithoutBuild())
+	assert.ErrorIs(t, err, ErrNotBuilt)
+	j, err := i.Invoke("*wheels.ServiceJ")
+	assert.NoError(t, err)
+	b, err := i.Invoke("*wheels.ServiceB", WithoutBuild())
+	assert.NoError(t, err)
+	c, _ := i.Invoke("*wheels.ServiceC")
+
+	nb, err := i.Invoke("*wheels.ServiceB", Fresh())
+	assert.NoError(t, err)
+	assert.NotSame(t, b, nb)
+	nc, _ := i.Invoke("*wheels.ServiceC")
+	assert.Same(t, c, nc)
+	_, err = i.Invoke("*wheels.ServiceJ", WithoutBuild())
+	assert.ErrorIs(t, err, ErrNotBuilt)
+	nj, _ := i.Invoke("*wheels.ServiceJ")
+	assert.NotSame(t, j, nj)
+
+	a, err := i.Invoke("*wheels.ServiceB", WithName("a"))
+	assert.NoError(t, err)
+	assert.IsType(t, &ServiceA{}, a)
+
+	_, err = i.Invoke("*wheels.ServiceF", WithTimeout(10*time.Millisecond))
+	var te *TimeoutError
+	assert.ErrorAs(t, err, &te)
+	assert.True(t, te.Timeout())
+
+	na, err := InvokeFrom[*ServiceA](i, WithName("a"))
+	assert.NoError(t, err)
+	assert.Same(t, a, na)
+}
+
+func TestInjector_ResolutionError(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.ProvideZero(&ServiceG{})
+	_ = i.Provide(newServiceJ)
+
+	_, err := i.Invoke("*wheels.ServiceJ")
+	var re *ResolutionError
+	assert.ErrorAs(t, err, &re)
+	assert.ErrorIs(t, err, ErrUnknownService)
+	assert.Equal(t, "*wheels.ServiceJ", re.Service)
+	assert.Equal(t, []string{"*wheels.ServiceJ", "wheels.ServiceTest", "*wheels.ServiceC"}, re.Path)
+	assert.Equal(t, 1, re.Param)
+	assert.Equal(t, "", re.Field)
+	assert.Equal(t, "name: *wheels.ServiceJ, path: *wheels.ServiceJ -> wheels.ServiceTest -> *wheels.ServiceC, "+
+		"param: 1, err: name: *wheels.ServiceC, err: unknown service", err.Error())
+
+	_ = i.Provide(newServiceF)
+	_, err = i.Invoke("*wheels.ServiceG")
+	assert.ErrorAs(t, err, &re)
+	assert.ErrorIs(t, err, ErrNewServiceF)
+	assert.Equal(t, []string{"*wheels.ServiceG", "*wheels.ServiceF"}, re.Path)
+	assert.Equal(t, -1, re.Param)
+	assert.Equal(t, "F", re.Field)
+}
+
+func TestInjector_ConstructorPanic(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	calls := 0
+	_ = i.Provide(func(a *ServiceA, c *ServiceC) *ServiceB {
+		calls++
+		if calls == 1 {
+			panic(ErrNewServiceF)
+		}
+		return &ServiceB{a: a, c: c}
+	}, As(new(ServiceTest)))
+	_ = i.Provide(newServiceJ)
+
+	_, err := i.Invoke("*wheels.ServiceJ")
+	var pe *PanicError
+	assert.ErrorAs(t, err, &pe)
+	assert.ErrorIs(t, err, ErrConstructorPanic)
+	assert.ErrorIs(t, err, ErrNewServiceF)
+	assert.Equal(t, "wheels.ServiceTest", pe.Name)
+	assert.Equal(t, []string{"*wheels.ServiceJ", "wheels.ServiceTest"}, pe.Path)
+	assert.Contains(t, string(pe.Stack), "TestInjector_ConstructorPanic")
+
+	j, err := i.Invoke("*wheels.ServiceJ")
+	assert.NoError(t, err)
+	assert.Equal(t, "B", j.(*ServiceJ).s.Print())
+	assert.Equal(t, 2, calls)
+}
+
+type cycleA struct{ b *cycleB }
+
+type cycleB struct{ a *cycleA }
+
+type cycleZ struct {
+	X *cycleX
+}
+
+type cycleX struct{ z *cycleZ }
+
+func TestInjector_CircularDependency(t *testing.T) {
+	i := New()
+	_ = i.Provide(func(b *cycleB) *cycleA { return &cycleA{b: b} })
+	_ = i.Provide(func(a *cycleA) *cycleB { return &cycleB{a: a} })
+	_ = i.ProvideZero(&cycleZ{})
+	_ = i.Provide(func(z *cycleZ) *cycleX { return &cycleX{z: z} })
+
+	_, err := i.Invoke("*wheels.cycleA")
+	assert.ErrorIs(t, err, ErrCircularDependency)
+	assert.Contains(t, err.Error(), "*wheels.cycleA -> *wheels.cycleB -> *wheels.cycleA")
+	assert.Contains(t, err.Error(), "ProvideZero")
+
+	ins, err := i.Invoke("*wheels.cycleZ")
+	assert.NoError(t, err)
+	z := ins.(*cycleZ)
+	assert.Same(t, z, z.X.z)
+}
+
+func TestInjector_Child(t *testing.T) {
+	parent := New()
+	_ = parent.ProvideInstance(&ServiceA{val: 1})
+	_ = parent.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = parent.ProvideZero(&ServiceC{})
+	_ = parent.ProvideZero(&ServiceD{})
+	_ = parent.ProvideZero(&ServiceH{})
+
+	child := parent.Child()
+	_ = child.Provide(newServiceJ)
+	_ = child.ProvideZero(&ServiceH{})
+
+	b, err := parent.Invoke("*wheels.ServiceB")
+	assert.NoError(t, err)
+	cb, err := child.Invoke("*wheels.ServiceB")
+	assert.NoError(t, err)
+	assert.Same(t, b, cb)
+
+	_, err = parent.Invoke("*wheels.ServiceJ")
+	assert.ErrorIs(t, err, ErrUnknownService)
+	j, err := child.Invoke("*wheels.ServiceJ")
+	assert.NoError(t, err)
+	assert.Equal(t, "B", j.(*ServiceJ).s.Print())
+
+	err = child.OverrideInstance(&ServiceA{val: 2}, As(new(ServiceTest)))
+	assert.NoError(t, err)
+	j, _ = child.Invoke("*wheels.ServiceJ")
+	assert.Equal(t, "A", j.(*ServiceJ).s.Print())
+	h, _ := child.Invoke("*wheels.ServiceH")
+	assert.Equal(t, "A", h.(*ServiceH).S.Print())
+	h, _ = parent.Invoke("*wheels.ServiceH")
+	assert.Equal(t, "B", h.(*ServiceH).S.Print())
+	a, _ := parent.Invoke("*wheels.ServiceA")
+	assert.Equal(t, 1, a.(*ServiceA).val)
+	// the services of the parent are built from the ones of the parent
+	d, _ := child.Invoke("*wheels.ServiceD")
+	assert.Equal(t, 1, d.(*ServiceD).A.val)
+	_ = child.ProvideZero(&ServiceD{})
+	d, _ = child.Invoke("*wheels.ServiceD")
+	assert.Equal(t, 2, d.(*ServiceD).A.val)
+}
+
+func TestInjector_ChildParentOverride(t *testing.T) {
+	parent := New()
+	_ = parent.ProvideInstance(&ServiceA{val: 1})
+	_ = parent.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = parent.ProvideZero(&ServiceC{})
+	_ = parent.ProvideZero(&ServiceD{})
+	child := parent.Child()
+	_ = child.Provide(newServiceJ)
+	_ = child.ProvideZero(&ServiceD{})
+	grandchild := child.Child()
+	_ = grandchild.ProvideZero(&ServiceH{})
+
+	d, _ := child.Invoke("*wheels.ServiceD")
+	assert.Equal(t, 1, d.(*ServiceD).A.val)
+	j, _ := child.Invoke("*wheels.ServiceJ")
+	h, _ := grandchild.Invoke("*wheels.ServiceH")
+	assert.Same(t, j.(*ServiceJ).s, h.(*ServiceH).S)
+
+	_ = parent.OverrideInstance(&ServiceA{val: 2})
+	d, _ = child.Invoke("*wheels.ServiceD")
+	assert.Equal(t, 2, d.(*ServiceD).A.val)
+
+	_ = parent.OverrideInstance(&ServiceA{val: 3}, As(new(ServiceTest)))
+	j, _ = child.Invoke("*wheels.ServiceJ")
+	assert.Equal(t, "A", j.(*ServiceJ).s.Print())
+	h, _ = grandchild.Invoke("*wheels.ServiceH")
+	assert.Equal(t, "A", h.(*ServiceH).S.Print())
+}
+
+func TestInjector_ChildCollected(t *testing.T) {
+	parent := New()
+	_ = parent.ProvideInstance(&ServiceA{val: 1}, As(new(ServiceTest)))
+	var collected atomic.Int32
+	for n := 0; n < 100; n++ {
+		child := parent.Child()
+		_ = child.Provide(newServiceJ)
+		_, err := child.Invoke("*wheels.ServiceJ")
+		assert.NoError(t, err)
+		runtime.SetFinalizer(child, func(*Injector) { collected.Add(1) })
+	}
+	for n := 0; n < 50 && collected.Load() < 100; n++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, int32(100), collected.Load())
+}
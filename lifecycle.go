@@ -0,0 +1,178 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"fmt"
+)
+
+// Starter is implemented by services that need to be started by Injector.Start.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Shutdowner is implemented by services that hold resources released by
+// Injector.Shutdown.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// Hook is a lifecycle callback registered with OnStart or OnShutdown, it
+// receives the instance built for the service.
+type Hook func(ctx context.Context, ins any) error
+
+type lifecycleEntry struct {
+	name       string
+	instance   any
+	onStart    []Hook
+	onShutdown []Hook
+}
+
+func (e *lifecycleEntry) start(ctx context.Context) error {
+	if s, ok := e.instance.(Starter); ok {
+		if err := s.Start(ctx); err != nil {
+			return fmt.Errorf("name: %v, err: %w", e.name, err)
+		}
+	}
+	for _, hook := range e.onStart {
+		if err := hook(ctx, e.instance); err != nil {
+			return fmt.Errorf("name: %v, err: %w", e.name, err)
+		}
+	}
+	return nil
+}
+
+func (e *lifecycleEntry) shutdown(ctx context.Context) error {
+	var errs []error
+	for _, hook := range e.onShutdown {
+		if err := hook(ctx, e.instance); err != nil {
+			errs = append(errs, fmt.Errorf("name: %v, err: %w", e.name, err))
+		}
+	}
+	if s, ok := e.instance.(Shutdowner); ok {
+		if err := s.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("name: %v, err: %w", e.name, err))
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// Start starts every built service that has not been started yet, dependencies
+// first. Services that are not built yet are left alone, invoke them before
+// calling Start. If a service fails to start, the services started by this
+// call are shut down again in reverse order and all errors are returned.
+func (i *Injector) Start(ctx context.Context) error {
+	entries := i.pendingStarts()
+	for j, e := range entries {
+		err := ctx.Err()
+		if err == nil {
+			err = e.start(ctx)
+		}
+		if err != nil {
+			errs := []error{err}
+			for k := j - 1; k >= 0; k-- {
+				errs = append(errs, entries[k].shutdown(ctx))
+			}
+			i.mu.Lock()
+			for _, e := range entries {
+				i.forgetStarted(e)
+			}
+			i.mu.Unlock()
+			return joinErrors(errs...)
+		}
+	}
+	return nil
+}
+
+// Shutdown shuts down every started service in the reverse order they were
+// started. All services are shut down even if some of them fail.
+func (i *Injector) Shutdown(ctx context.Context) error {
+	i.mu.Lock()
+	entries := i.started
+	i.started = nil
+	i.running = map[Service]*lifecycleEntry{}
+	i.mu.Unlock()
+	var errs []error
+	for j := len(entries) - 1; j >= 0; j-- {
+		errs = append(errs, entries[j].shutdown(ctx))
+	}
+	return joinErrors(errs...)
+}
+
+// pendingStarts returns the built services that are not running, sorted in
+// dependency order, and records them as started.
+func (i *Injector) pendingStarts() []*lifecycleEntry {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var entries []*lifecycleEntry
+	for _, svc := range i.sortedServicesLocked() {
+		if _, ok := i.running[svc]; ok {
+			continue
+		}
+		ins, built := svc.getBuilt()
+		if !built {
+			continue
+		}
+		e := &lifecycleEntry{name: svc.getName(), instance: ins}
+		if opts := i.serviceOptions[svc]; opts != nil {
+			e.onStart = opts.OnStart
+			e.onShutdown = opts.OnShutdown
+		}
+		i.running[svc] = e
+		i.started = append(i.started, e)
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func (i *Injector) forgetStarted(e *lifecycleEntry) {
+	for svc, r := range i.running {
+		if r == e {
+			delete(i.running, svc)
+		}
+	}
+	for j, s := range i.started {
+		if s == e {
+			i.started = append(i.started[:j], i.started[j+1:]...)
+			break
+		}
+	}
+}
+
+// sortedServicesLocked returns the registered services so that every service
+// comes after the services it was built from. Circular dependencies between
+// zero services are broken at the first service visited.
+func (i *Injector) sortedServicesLocked() []Service {
+	visited := map[Service]bool{}
+	var sorted []Service
+	var visit func(svc Service)
+	visit = func(svc Service) {
+		if visited[svc] {
+			return
+		}
+		visited[svc] = true
+		for _, dep := range i.dependenciesLocked(svc) {
+			visit(dep)
+		}
+		sorted = append(sorted, svc)
+	}
+	for _, svc := range i.registeredServicesLocked() {
+		visit(svc)
+	}
+	return sorted
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lifecycleLog struct {
+	events []string
+}
+
+type lifecycleDB struct {
+	log *lifecycleLog
+	err error
+}
+
+func (d *lifecycleDB) Start(ctx context.Context) error {
+	d.log.events = append(d.log.events, "start db")
+	return d.err
+}
+
+func (d *lifecycleDB) Shutdown(ctx context.Context) error {
+	d.log.events = append(d.log.events, "shutdown db")
+	return nil
+}
+
+type lifecycleServer struct {
+	DB  *lifecycleDB
+	Log *lifecycleLog
+}
+
+func (s *lifecycleServer) Start(ctx context.Context) error {
+	s.Log.events = append(s.Log.events, "start server")
+	return nil
+}
+
+func (s *lifecycleServer) Shutdown(ctx context.Context) error {
+	s.Log.events = append(s.Log.events, "shutdown server")
+	return nil
+}
+
+var errLifecycle = errors.New("lifecycle failed")
+
+func TestInjector_StartShutdown(t *testing.T) {
+	i := New()
+	log := &lifecycleLog{}
+	_ = i.ProvideInstance(log)
+	_ = i.ProvideZero(&lifecycleServer{}, OnStart(func(ctx context.Context, ins any) error {
+		log.events = append(log.events, "hook server")
+		return nil
+	}))
+	_ = i.Provide(func(log *lifecycleLog) *lifecycleDB {
+		return &lifecycleDB{log: log}
+	}, OnShutdown(func(ctx context.Context, ins any) error {
+		return errLifecycle
+	}))
+	_, err := i.Invoke("*wheels.lifecycleServer")
+	assert.NoError(t, err)
+
+	assert.NoError(t, i.Start(context.Background()))
+	assert.NoError(t, i.Start(context.Background()))
+	assert.Equal(t, []string{"start db", "start server", "hook server"}, log.events)
+
+	log.events = nil
+	err = i.Shutdown(context.Background())
+	assert.ErrorIs(t, err, errLifecycle)
+	assert.Equal(t, []string{"shutdown server", "shutdown db"}, log.events)
+
+	log.events = nil
+	assert.NoError(t, i.Shutdown(context.Background()))
+	assert.Empty(t, log.events)
+}
+
+func TestInjector_StartRollback(t *testing.T) {
+	i := New()
+	log := &lifecycleLog{}
+	_ = i.ProvideInstance(log)
+	_ = i.Provide(func(log *lifecycleLog) *lifecycleDB {
+		return &lifecycleDB{log: log}
+	})
+	_ = i.ProvideZero(&lifecycleServer{}, OnStart(func(ctx context.Context, ins any) error {
+		return errLifecycle
+	}))
+	_, err := i.Invoke("*wheels.lifecycleServer")
+	assert.NoError(t, err)
+
+	err = i.Start(context.Background())
+	assert.ErrorIs(t, err, errLifecycle)
+	assert.Equal(t, []string{"start db", "start server", "shutdown db"}, log.events)
+
+	log.events = nil
+	assert.NoError(t, i.Shutdown(context.Background()))
+	assert.Empty(t, log.events)
+}
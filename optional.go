@@ -0,0 +1,60 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import "reflect"
+
+// Optional is a ctor param that holds the service of type T if it is
+// registered and is left empty otherwise. Providing T later rebuilds the
+// services that went without it.
+type Optional[T any] struct {
+	value T
+	ok    bool
+}
+
+// Get returns the service and whether it was registered.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+// OrElse returns the service, or def if it was not registered.
+func (o Optional[T]) OrElse(def T) T {
+	if !o.ok {
+		return def
+	}
+	return o.value
+}
+
+func (o *Optional[T]) elemType() reflect.Type {
+	return reflect.TypeOf(&o.value).Elem()
+}
+
+func (o *Optional[T]) set(val reflect.Value) {
+	reflect.ValueOf(&o.value).Elem().Set(val)
+	o.ok = true
+}
+
+type optionalParam interface {
+	elemType() reflect.Type
+	set(reflect.Value)
+}
+
+var optionalParamType = reflect.TypeOf(new(optionalParam)).Elem()
+
+func isOptional(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && reflect.PointerTo(typ).Implements(optionalParamType)
+}
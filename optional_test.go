@@ -0,0 +1,58 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type optionalZero struct {
+	A *ServiceA   `wheels:"optional"`
+	S ServiceTest `wheels:"optional"`
+}
+
+type optionalLazy struct {
+	s ServiceTest
+}
+
+func TestInjector_Optional(t *testing.T) {
+	i := New()
+	_ = i.ProvideZero(&optionalZero{})
+	_ = i.Provide(func(s Optional[ServiceTest]) *optionalLazy {
+		return &optionalLazy{s: s.OrElse(nil)}
+	})
+
+	z, err := i.Invoke("*wheels.optionalZero")
+	assert.NoError(t, err)
+	assert.Nil(t, z.(*optionalZero).A)
+	assert.Nil(t, z.(*optionalZero).S)
+	l, err := i.Invoke("*wheels.optionalLazy")
+	assert.NoError(t, err)
+	assert.Nil(t, l.(*optionalLazy).s)
+
+	_ = i.Provide(NewServiceA, As(new(ServiceTest)))
+
+	z, err = i.Invoke("*wheels.optionalZero")
+	assert.NoError(t, err)
+	assert.NotNil(t, z.(*optionalZero).A)
+	assert.Equal(t, "A", z.(*optionalZero).S.Print())
+	l, err = i.Invoke("*wheels.optionalLazy")
+	assert.NoError(t, err)
+	assert.Equal(t, "A", l.(*optionalLazy).s.Print())
+}
@@ -16,10 +16,29 @@ limitations under the License.
 
 package wheels
 
+import (
+	"reflect"
+	"time"
+)
+
 type providerOptions struct {
-	Name       string
-	As         []any
-	IsOverride bool
+	Name         string
+	As           []any
+	Interceptors []Interceptor
+	IsOverride   bool
+	OnStart      []Hook
+	OnShutdown   []Hook
+	Groups       []string
+	Transient    bool
+	Scope        string
+	Eager        bool
+	Retry        *RetryPolicy
+
+	interceptedAs map[string]reflect.Type
+}
+
+func (po *providerOptions) isSingleton() bool {
+	return !po.Transient && po.Scope == ""
 }
 
 type ProvideOption func(*providerOptions)
@@ -30,13 +49,127 @@ func Name(name string) ProvideOption {
 	}
 }
 
+// As also provides the service as the given interfaces, passed as pointers
+// like new(Iface). Interceptors passed along, e.g. Around(fn), run around
+// the method calls made through these interfaces.
 func As(ifaceOrAOP ...any) ProvideOption {
 	return func(po *providerOptions) {
-		po.As = append(po.As, ifaceOrAOP...)
+		for _, v := range ifaceOrAOP {
+			if ic, ok := v.(Interceptor); ok {
+				po.Interceptors = append(po.Interceptors, ic)
+				continue
+			}
+			po.As = append(po.As, v)
+		}
+	}
+}
+
+// Transient calls the ctor, or allocates the zero value, every time the
+// service is invoked or injected instead of caching a single instance.
+func Transient() ProvideOption {
+	return func(po *providerOptions) {
+		po.Transient = true
+	}
+}
+
+// Scoped builds the service once per Scope created with Injector.NewScope(scope).
+// Invoking it outside such a scope, or injecting it into a singleton, fails.
+func Scoped(scope string) ProvideOption {
+	return func(po *providerOptions) {
+		po.Scope = scope
+	}
+}
+
+// Eager builds the service right away when it is provided, and again when an
+// override or a decorator resets it, instead of at the next Invoke. If the
+// build fails, the Provide, Override or Decorate call is undone as by
+// Snapshot.Restore and returns the error named after the eager service, so
+// the dependencies of an eager service must be provided before it.
+func Eager() ProvideOption {
+	return func(po *providerOptions) {
+		po.Eager = true
+	}
+}
+
+// Retry calls the ctor of the service again as set by policy when it fails
+// or panics. It fails the Provide or Override of a service that has no ctor,
+// or if policy.Attempts is less than 1.
+func Retry(policy RetryPolicy) ProvideOption {
+	return func(po *providerOptions) {
+		po.Retry = &policy
+	}
+}
+
+// Group adds the service to a value group. A field tagged
+// `wheels:"group=name"` collects every member of the group in registration
+// order, an untagged []T dependency collects the group named after T if it
+// has members.
+func Group(name string) ProvideOption {
+	return func(po *providerOptions) {
+		po.Groups = append(po.Groups, name)
+	}
+}
+
+// OnStart registers a hook that Injector.Start runs once the service is built.
+func OnStart(hook Hook) ProvideOption {
+	return func(po *providerOptions) {
+		po.OnStart = append(po.OnStart, hook)
+	}
+}
+
+// OnShutdown registers a hook that Injector.Shutdown runs for a started service.
+func OnShutdown(hook Hook) ProvideOption {
+	return func(po *providerOptions) {
+		po.OnShutdown = append(po.OnShutdown, hook)
 	}
 }
 
 type invokeOptions struct {
+	Name         string
+	WithoutBuild bool
+	Fresh        bool
+	Timeout      time.Duration
+}
+
+func newInvokeOptions(opts []InvokeOption) *invokeOptions {
+	options := &invokeOptions{}
+	for _, io := range opts {
+		io(options)
+	}
+	return options
 }
 
 type InvokeOption func(*invokeOptions)
+
+// WithName invokes the service name instead of the one named after the type
+// asked for, e.g. with Invoke[T].
+func WithName(name string) InvokeOption {
+	return func(io *invokeOptions) {
+		io.Name = name
+	}
+}
+
+// WithoutBuild returns the service only if it is already built and fails
+// with ErrNotBuilt otherwise.
+func WithoutBuild() InvokeOption {
+	return func(io *invokeOptions) {
+		io.WithoutBuild = true
+	}
+}
+
+// Fresh builds the service again even if it is already built, its
+// dependencies are not rebuilt. The services built from the previous
+// instance are reset like after an override.
+func Fresh() InvokeOption {
+	return func(io *invokeOptions) {
+		io.Fresh = true
+	}
+}
+
+// WithTimeout stops building the service once d has elapsed, the ctors that
+// take a context.Context get its deadline.
+func WithTimeout(d time.Duration) InvokeOption {
+	return func(io *invokeOptions) {
+		io.Timeout = d
+	}
+}
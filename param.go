@@ -0,0 +1,281 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const tagKey = "wheels"
+
+// In marks a ctor param as a param object. Embed it in a struct taken by a
+// ctor and every exported field of the struct is injected like a field of
+// a zero service, honoring the wheels tags:
+//
+//	type Params struct {
+//		wheels.In
+//		Primary *sql.DB `wheels:"name=primary-db"`
+//		Replica *sql.DB `wheels:"name=replica-db"`
+//	}
+type In struct{}
+
+var inType = reflect.TypeOf(In{})
+
+// fieldTag is the parsed wheels tag of a struct field, e.g.
+// `wheels:"name=primary-db,optional"` or `wheels:"group=handlers"`.
+type fieldTag struct {
+	name     string
+	group    string
+	optional bool
+}
+
+func parseFieldTag(tag reflect.StructTag) fieldTag {
+	var ft fieldTag
+	for _, opt := range strings.Split(tag.Get(tagKey), ",") {
+		key, val, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		switch key {
+		case "name":
+			ft.name = val
+		case "group":
+			ft.group = val
+		case "optional":
+			ft.optional = true
+		}
+	}
+	return ft
+}
+
+// dependency describes what is injected into a ctor param or a struct field.
+type dependency struct {
+	typ      reflect.Type
+	name     string // service name, or group name if group is set
+	group    bool
+	optional bool
+}
+
+// key returns the name the dependency is tracked by in paramNames.
+func (d dependency) key() string {
+	if d.group {
+		return groupKey(d.name)
+	}
+	return d.name
+}
+
+// typeDependencyLocked returns the dependency on the service named after typ.
+// A slice that is not registered itself collects the group named after its
+// element type, if the group has members.
+func (i *Injector) typeDependencyLocked(typ reflect.Type) dependency {
+	name := typ.String()
+	if typ.Kind() == reflect.Slice && !i.hasServiceLocked(name) && i.hasGroupLocked(typ.Elem().String()) {
+		return dependency{typ: typ, name: typ.Elem().String(), group: true}
+	}
+	return dependency{typ: typ, name: name}
+}
+
+func (i *Injector) fieldDependencyLocked(field reflect.StructField) dependency {
+	ft := parseFieldTag(field.Tag)
+	var dep dependency
+	switch {
+	case ft.group != "":
+		dep = dependency{typ: field.Type, name: ft.group, group: true}
+	case ft.name != "":
+		dep = dependency{typ: field.Type, name: ft.name}
+	default:
+		dep = i.typeDependencyLocked(field.Type)
+	}
+	dep.optional = ft.optional
+	return dep
+}
+
+// getDependency resolves dep. ok is false if dep is optional and the service
+// is not registered.
+func (i *Injector) getDependency(ctx context.Context, dep dependency) (val reflect.Value, ok bool, err error) {
+	switch {
+	case dep.group:
+		val, err = i.getGroupValue(ctx, dep.name, dep.typ)
+	case dep.optional:
+		return i.getOptionalValue(ctx, dep.name)
+	default:
+		val, err = i.getValue(ctx, dep.name)
+	}
+	return val, err == nil, err
+}
+
+// getFieldValue resolves the service injected into field.
+func (i *Injector) getFieldValue(ctx context.Context, field reflect.StructField) (val reflect.Value, pname string, ok bool, err error) {
+	i.mu.RLock()
+	dep := i.fieldDependencyLocked(field)
+	i.mu.RUnlock()
+	val, ok, err = i.getDependency(ctx, dep)
+	// a service injected by name may have any type
+	if err == nil && ok && !val.Type().AssignableTo(field.Type) {
+		err = fmt.Errorf("name: %v, type: %v, err: %w", dep.name, field.Type.String(), ErrInvalidFieldType)
+	}
+	return val, dep.key(), ok, err
+}
+
+func isParamObject(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	for j := 0; j < typ.NumField(); j++ {
+		if f := typ.Field(j); f.Anonymous && f.Type == inType {
+			return true
+		}
+	}
+	return false
+}
+
+// getParamObject builds a param object of type typ, passed as the
+// param at index param, and returns the names of the services injected into
+// it.
+func (i *Injector) getParamObject(ctx context.Context, typ reflect.Type, param int) (val reflect.Value, pnames []string, err error) {
+	val = reflect.New(typ).Elem()
+	for j := 0; j < typ.NumField(); j++ {
+		field := typ.Field(j)
+		if field.Type == inType || !field.IsExported() {
+			continue
+		}
+		pvalue, pname, ok, err := i.getFieldValue(ctx, field)
+		if err != nil {
+			return val, pnames, newResolutionError(ctx, pname, param, field.Name, err)
+		}
+		if ok {
+			val.Field(j).Set(pvalue)
+		}
+		pnames = append(pnames, pname)
+	}
+	return val, pnames, nil
+}
+
+// call calls fn, a ctor or a decorator, with args followed by its other
+// params resolved from the injector, it returns the first result and the
+// names of the services passed. If the first resolved param is a
+// context.Context, it receives the ctx of the invocation.
+func (i *Injector) call(ctx context.Context, name string, fn reflect.Value, args ...reflect.Value) (val reflect.Value, pnames []string, err error) {
+	ftype := fn.Type()
+	paramValues := make([]reflect.Value, ftype.NumIn())
+	copy(paramValues, args)
+	for j := len(args); j < ftype.NumIn(); j++ {
+		ptype := ftype.In(j)
+		if j == len(args) && ptype == ctxType {
+			paramValues[j] = reflect.ValueOf(&ctx).Elem()
+			continue
+		}
+		if isOptional(ptype) {
+			opt := reflect.New(ptype)
+			pname := opt.Interface().(optionalParam).elemType().String()
+			pvalue, ok, err := i.getOptionalValue(ctx, pname)
+			if err != nil {
+				return val, nil, newResolutionError(ctx, pname, j, "", err)
+			}
+			if ok {
+				opt.Interface().(optionalParam).set(pvalue)
+			}
+			paramValues[j] = opt.Elem()
+			pnames = append(pnames, pname)
+			continue
+		}
+		if isParamObject(ptype) {
+			pvalue, objNames, err := i.getParamObject(ctx, ptype, j)
+			if err != nil {
+				return val, nil, err
+			}
+			paramValues[j] = pvalue
+			pnames = append(pnames, objNames...)
+			continue
+		}
+		i.mu.RLock()
+		dep := i.typeDependencyLocked(ptype)
+		i.mu.RUnlock()
+		pvalue, _, err := i.getDependency(ctx, dep)
+		if err != nil {
+			return val, nil, newResolutionError(ctx, dep.key(), j, "", err)
+		}
+		paramValues[j] = pvalue
+		pnames = append(pnames, dep.key())
+	}
+	if ctx.Err() != nil {
+		return val, nil, newTimeoutError(name, ctx.Err())
+	}
+	retValues, err := callRecover(ctx, name, fn, paramValues)
+	if err != nil {
+		return val, nil, err
+	}
+	if len(retValues) == 2 {
+		errValue := retValues[1]
+		if !errValue.IsNil() {
+			err = errValue.Interface().(error)
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			return val, nil, newTimeoutError(name, err)
+		}
+		return val, nil, err
+	}
+	return retValues[0], pnames, nil
+}
+
+// callRecover calls fn with args, a panic is returned as a PanicError. The
+// services resolved for the call stay built, so the call can be retried.
+func callRecover(ctx context.Context, name string, fn reflect.Value, args []reflect.Value) (out []reflect.Value, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = newPanicError(ctx, name, v)
+		}
+	}()
+	return fn.Call(args), nil
+}
+
+// funcDependenciesLocked returns the dependencies injected into the params of
+// ftype after the nargs fixed args, in the order call resolves them.
+func (i *Injector) funcDependenciesLocked(ftype reflect.Type, nargs int) []dependency {
+	var deps []dependency
+	for j := nargs; j < ftype.NumIn(); j++ {
+		ptype := ftype.In(j)
+		switch {
+		case j == nargs && ptype == ctxType:
+		case isOptional(ptype):
+			elem := reflect.New(ptype).Interface().(optionalParam).elemType()
+			deps = append(deps, dependency{typ: elem, name: elem.String(), optional: true})
+		case isParamObject(ptype):
+			deps = append(deps, i.structDependenciesLocked(ptype)...)
+		default:
+			deps = append(deps, i.typeDependencyLocked(ptype))
+		}
+	}
+	return deps
+}
+
+// structDependenciesLocked returns the dependencies injected into the exported
+// fields of the struct typ.
+func (i *Injector) structDependenciesLocked(typ reflect.Type) []dependency {
+	var deps []dependency
+	for j := 0; j < typ.NumField(); j++ {
+		field := typ.Field(j)
+		if field.Type == inType || !field.IsExported() {
+			continue
+		}
+		deps = append(deps, i.fieldDependencyLocked(field))
+	}
+	return deps
+}
@@ -0,0 +1,89 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type namedDB struct {
+	dsn string
+}
+
+type namedRepo struct {
+	Primary *namedDB `wheels:"name=primary-db"`
+	Replica *namedDB `wheels:"name=replica-db"`
+}
+
+type namedParams struct {
+	In
+	Primary *namedDB `wheels:"name=primary-db"`
+	Replica *namedDB `wheels:"name=replica-db"`
+	A       *ServiceA
+}
+
+type namedCache struct {
+	primary *namedDB
+	replica *namedDB
+}
+
+func TestInjector_NamedInjection(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&namedDB{dsn: "primary"}, Name("primary-db"))
+	_ = i.Provide(func() *namedDB { return &namedDB{dsn: "replica"} }, Name("replica-db"))
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.ProvideZero(&namedRepo{})
+	_ = i.Provide(func(p namedParams) *namedCache {
+		return &namedCache{primary: p.Primary, replica: p.Replica}
+	})
+
+	repo, err := i.Invoke("*wheels.namedRepo")
+	assert.NoError(t, err)
+	assert.Equal(t, "primary", repo.(*namedRepo).Primary.dsn)
+	assert.Equal(t, "replica", repo.(*namedRepo).Replica.dsn)
+
+	cache, err := i.Invoke("*wheels.namedCache")
+	assert.NoError(t, err)
+	assert.Equal(t, "primary", cache.(*namedCache).primary.dsn)
+	assert.Equal(t, "replica", cache.(*namedCache).replica.dsn)
+
+	err = i.OverrideInstance(&namedDB{dsn: "new primary"}, Name("primary-db"))
+	assert.NoError(t, err)
+	cache, err = i.Invoke("*wheels.namedCache")
+	assert.NoError(t, err)
+	assert.Equal(t, "new primary", cache.(*namedCache).primary.dsn)
+}
+
+func TestInjector_NamedInjectionType(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{}, Name("primary-db"))
+	_ = i.ProvideInstance(&namedDB{}, Name("replica-db"))
+	_ = i.ProvideZero(&namedRepo{})
+	_ = i.Provide(func(p namedParams) *namedCache { return &namedCache{} })
+
+	_, err := i.Invoke("*wheels.namedRepo")
+	assert.ErrorIs(t, err, ErrInvalidFieldType)
+	assert.EqualError(t, err, "name: *wheels.namedRepo, path: *wheels.namedRepo -> primary-db, field: Primary, err: name: primary-db, type: *wheels.namedDB, err: invalid field type")
+
+	_, err = i.Invoke("*wheels.namedCache")
+	var re *ResolutionError
+	assert.ErrorAs(t, err, &re)
+	assert.ErrorIs(t, err, ErrInvalidFieldType)
+	assert.Equal(t, "Primary", re.Field)
+}
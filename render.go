@@ -0,0 +1,171 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the graph of i in the Graphviz DOT language.
+func (i *Injector) WriteDOT(w io.Writer) error {
+	return i.Graph().WriteDOT(w)
+}
+
+// WriteMermaid writes the graph of i as a Mermaid flowchart.
+func (i *Injector) WriteMermaid(w io.Writer) error {
+	return i.Graph().WriteMermaid(w)
+}
+
+// WriteDOT writes g in the Graphviz DOT language. Lazy services are boxes,
+// zero services rounded boxes and instances notes, built services are bold.
+// Aliases are ellipses pointing at their service. Ctor edges are solid, field
+// edges dashed, decorator edges dotted and circular edges red.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph wheels {\n\trankdir=LR;\n")
+	for _, n := range g.Nodes {
+		var attrs []string
+		switch n.Kind {
+		case KindLazy:
+			attrs = append(attrs, "shape=box")
+		case KindZero:
+			attrs = append(attrs, "shape=box", `style="rounded"`)
+		case KindInstance:
+			attrs = append(attrs, "shape=note")
+		}
+		if n.Built {
+			attrs = append(attrs, "penwidth=2")
+		}
+		label := n.Name + "\n" + string(n.Kind)
+		attrs = append(attrs, "label="+strconv.Quote(label))
+		fmt.Fprintf(&b, "\t%s [%s];\n", strconv.Quote(n.Name), strings.Join(attrs, ", "))
+		for _, alias := range n.Aliases {
+			fmt.Fprintf(&b, "\t%s [shape=ellipse, style=dashed];\n", strconv.Quote(alias))
+			fmt.Fprintf(&b, "\t%s -> %s [style=dashed, arrowhead=empty];\n", strconv.Quote(alias), strconv.Quote(n.Name))
+		}
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		switch e.Kind {
+		case EdgeField:
+			attrs = append(attrs, "style=dashed")
+		case EdgeDecorator:
+			attrs = append(attrs, "style=dotted")
+		}
+		if e.Circular {
+			attrs = append(attrs, "color=red")
+		}
+		if label := edgeLabel(e); label != "" {
+			attrs = append(attrs, "label="+strconv.Quote(label))
+		}
+		fmt.Fprintf(&b, "\t%s -> %s", strconv.Quote(e.From), strconv.Quote(edgeTarget(e)))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes g as a Mermaid flowchart. Lazy services are rectangles,
+// zero services rounded rectangles and instances parallelograms, built
+// services are bold. Aliases are stadiums pointing at their service. Ctor
+// edges are solid, field and decorator edges dotted and circular edges thick.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	ids := map[string]string{}
+	id := func(name string) string {
+		if v, ok := ids[name]; ok {
+			return v
+		}
+		ids[name] = fmt.Sprintf("n%d", len(ids))
+		return ids[name]
+	}
+	var built []string
+	for _, n := range g.Nodes {
+		label := mermaidText(n.Name) + "<br/>" + string(n.Kind)
+		switch n.Kind {
+		case KindZero:
+			fmt.Fprintf(&b, "\t%s(\"%s\")\n", id(n.Name), label)
+		case KindInstance:
+			fmt.Fprintf(&b, "\t%s[/\"%s\"/]\n", id(n.Name), label)
+		default:
+			fmt.Fprintf(&b, "\t%s[\"%s\"]\n", id(n.Name), label)
+		}
+		if n.Built {
+			built = append(built, id(n.Name))
+		}
+		for _, alias := range n.Aliases {
+			fmt.Fprintf(&b, "\t%s([\"%s\"])\n", id(alias), mermaidText(alias))
+			fmt.Fprintf(&b, "\t%s -. as .-> %s\n", id(alias), id(n.Name))
+		}
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		switch {
+		case e.Circular:
+			arrow = "==>"
+		case e.Kind != EdgeCtor:
+			arrow = "-.->"
+		}
+		if label := edgeLabel(e); label != "" {
+			arrow += "|" + mermaidText(label) + "|"
+		}
+		fmt.Fprintf(&b, "\t%s %s %s\n", id(e.From), arrow, id(edgeTarget(e)))
+	}
+	if len(built) > 0 {
+		b.WriteString("\tclassDef built stroke-width:3px\n")
+		fmt.Fprintf(&b, "\tclass %s built\n", strings.Join(built, ","))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// edgeTarget returns the alias an edge is resolved by, or the service it
+// points at.
+func edgeTarget(e GraphEdge) string {
+	if !e.Group && e.Ref != "" {
+		return e.Ref
+	}
+	return e.To
+}
+
+func edgeLabel(e GraphEdge) string {
+	var parts []string
+	if e.Kind == EdgeDecorator {
+		parts = append(parts, "decorator")
+	}
+	if e.Group {
+		parts = append(parts, "group "+e.Ref)
+	}
+	if e.Optional {
+		parts = append(parts, "optional")
+	}
+	return strings.Join(parts, ", ")
+}
+
+var mermaidReplacer = strings.NewReplacer(`"`, "#quot;", "|", "#124;", "<", "#lt;", ">", "#gt;")
+
+func mermaidText(s string) string {
+	return mermaidReplacer.Replace(s)
+}
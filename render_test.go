@@ -0,0 +1,81 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRenderInjector() *Injector {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	_ = i.Provide(newServiceJ)
+	_, _ = i.Invoke("*wheels.ServiceA")
+	return i
+}
+
+func TestInjector_WriteDOT(t *testing.T) {
+	var b strings.Builder
+	err := newRenderInjector().WriteDOT(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph wheels {
+	rankdir=LR;
+	"*wheels.ServiceA" [shape=note, penwidth=2, label="*wheels.ServiceA\ninstance"];
+	"*wheels.ServiceB" [shape=box, label="*wheels.ServiceB\nlazy"];
+	"wheels.ServiceTest" [shape=ellipse, style=dashed];
+	"wheels.ServiceTest" -> "*wheels.ServiceB" [style=dashed, arrowhead=empty];
+	"*wheels.ServiceC" [shape=box, style="rounded", label="*wheels.ServiceC\nzero"];
+	"*wheels.ServiceD" [shape=box, style="rounded", label="*wheels.ServiceD\nzero"];
+	"*wheels.ServiceJ" [shape=box, label="*wheels.ServiceJ\nlazy"];
+	"*wheels.ServiceB" -> "*wheels.ServiceA";
+	"*wheels.ServiceB" -> "*wheels.ServiceC";
+	"*wheels.ServiceC" -> "*wheels.ServiceD" [style=dashed, color=red];
+	"*wheels.ServiceD" -> "*wheels.ServiceC" [style=dashed, color=red];
+	"*wheels.ServiceD" -> "*wheels.ServiceA" [style=dashed];
+	"*wheels.ServiceJ" -> "wheels.ServiceTest";
+}
+`, b.String())
+}
+
+func TestInjector_WriteMermaid(t *testing.T) {
+	var b strings.Builder
+	err := newRenderInjector().WriteMermaid(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, `flowchart LR
+	n0[/"*wheels.ServiceA<br/>instance"/]
+	n1["*wheels.ServiceB<br/>lazy"]
+	n2(["wheels.ServiceTest"])
+	n2 -. as .-> n1
+	n3("*wheels.ServiceC<br/>zero")
+	n4("*wheels.ServiceD<br/>zero")
+	n5["*wheels.ServiceJ<br/>lazy"]
+	n1 --> n0
+	n1 --> n3
+	n3 ==> n4
+	n4 ==> n3
+	n4 -.-> n0
+	n5 --> n2
+	classDef built stroke-width:3px
+	class n0 built
+`, b.String())
+}
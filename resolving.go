@@ -0,0 +1,108 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type resolvingKey struct{}
+
+// resolving is a service in the chain of services being resolved by an
+// invocation, it is carried in the context passed to the services.
+type resolving struct {
+	name   string
+	svc    Service
+	opts   *providerOptions
+	parent *resolving
+}
+
+func withResolving(ctx context.Context, name string, svc Service, opts *providerOptions) context.Context {
+	return context.WithValue(ctx, resolvingKey{}, &resolving{
+		name:   name,
+		svc:    svc,
+		opts:   opts,
+		parent: resolvingFrom(ctx),
+	})
+}
+
+func resolvingFrom(ctx context.Context) *resolving {
+	r, _ := ctx.Value(resolvingKey{}).(*resolving)
+	return r
+}
+
+// find returns the innermost link resolving svc.
+func (r *resolving) find(svc Service) *resolving {
+	for ; r != nil; r = r.parent {
+		if r.svc == svc {
+			return r
+		}
+	}
+	return nil
+}
+
+// chain returns the links from the root of the invocation down to r.
+func (r *resolving) chain() []*resolving {
+	var links []*resolving
+	for ; r != nil; r = r.parent {
+		links = append(links, r)
+	}
+	for j, k := 0, len(links)-1; j < k; j, k = j+1, k-1 {
+		links[j], links[k] = links[k], links[j]
+	}
+	return links
+}
+
+// newCircularDependencyError reports that resolving name from r leads back to
+// from, which is already being built.
+func newCircularDependencyError(r, from *resolving, name string) error {
+	var names, candidates []string
+	inCycle := false
+	for _, link := range r.chain() {
+		if link == from {
+			inCycle = true
+		}
+		if !inCycle {
+			continue
+		}
+		names = append(names, link.name)
+		if _, ok := link.svc.(*ServiceLazy); ok && isZeroCandidate(link.svc.getType()) {
+			candidates = append(candidates, link.name)
+		}
+	}
+	names = append(names, name)
+	return circularDependencyError(names, candidates)
+}
+
+// circularDependencyError reports the cycle of names, suggesting to provide
+// one of the candidates with ProvideZero.
+func circularDependencyError(names, candidates []string) error {
+	if len(candidates) == 0 {
+		return fmt.Errorf("path: %v, err: %w", strings.Join(names, " -> "), ErrCircularDependency)
+	}
+	return fmt.Errorf("path: %v, err: %w, hint: provide %v with ProvideZero to break the cycle",
+		strings.Join(names, " -> "), ErrCircularDependency, strings.Join(candidates, " or "))
+}
+
+// isZeroCandidate reports whether a service of type typ could be provided
+// with ProvideZero instead.
+func isZeroCandidate(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Pointer && typ.Elem().Kind() == reflect.Struct
+}
@@ -0,0 +1,109 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+// RetryPolicy sets how often and when the ctor of a service provided with
+// the Retry option is called again after it failed.
+type RetryPolicy struct {
+	// Attempts is the maximum number of calls, including the first one. It
+	// must be at least 1, which never retries.
+	Attempts int
+	// Delay is the wait before the first retry.
+	Delay time.Duration
+	// MaxDelay caps the wait between two calls, if set.
+	MaxDelay time.Duration
+	// Multiplier grows the wait after each retry, 2 if unset.
+	Multiplier float64
+	// Jitter shortens each wait by a random fraction of up to Jitter, from
+	// 0 to 1, so services failing together do not retry together.
+	Jitter float64
+	// Retryable reports whether the ctor should be called again after err,
+	// every error is retried if unset.
+	Retryable func(err error) bool
+}
+
+// call calls the ctor fn until it succeeds or the policy gives up. Only the
+// errors of fn itself are retried, not those of its dependencies or of the
+// context.
+func (p *RetryPolicy) call(ctx context.Context, i *Injector, name string, fn reflect.Value) (val reflect.Value, pnames []string, err error) {
+	for attempt := 1; ; attempt++ {
+		val, pnames, err = i.call(ctx, name, fn)
+		if err == nil {
+			return val, pnames, nil
+		}
+		if !p.retryable(err) {
+			if attempt == 1 {
+				return val, nil, err
+			}
+			return val, nil, &RetryError{Name: name, Attempts: attempt, Err: err}
+		}
+		if attempt >= p.Attempts {
+			if attempt == 1 {
+				return val, nil, err
+			}
+			return val, nil, &RetryError{Name: name, Attempts: attempt, Err: err}
+		}
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return val, nil, &RetryError{Name: name, Attempts: attempt, Err: joinErrors(err, newTimeoutError(name, ctx.Err()))}
+		case <-timer.C:
+		}
+	}
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	var (
+		resErr     *ResolutionError
+		timeoutErr *TimeoutError
+	)
+	if errors.As(err, &resErr) || errors.As(err, &timeoutErr) || errors.Is(err, ErrCircularDependency) {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// backoff returns the wait after the given failed attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	d := float64(p.Delay)
+	for j := 1; j < attempt; j++ {
+		d *= multiplier
+		if p.MaxDelay > 0 && d >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d -= d * p.Jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
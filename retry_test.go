@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errDial = errors.New("dial failed")
+
+func TestInjector_Retry(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	calls := 0
+	err := i.Provide(func(a *ServiceA) (*ServiceB, error) {
+		calls++
+		if calls < 3 {
+			return nil, errDial
+		}
+		return &ServiceB{a: a}, nil
+	}, Retry(RetryPolicy{Attempts: 3, Delay: time.Millisecond}))
+	assert.NoError(t, err)
+	b, err := i.Invoke("*wheels.ServiceB")
+	assert.NoError(t, err)
+	assert.NotNil(t, b)
+	assert.Equal(t, 3, calls)
+
+	// gives up
+	calls = 0
+	_ = i.Override(func() (*ServiceB, error) {
+		calls++
+		if calls == 2 {
+			panic(ErrNewServiceF)
+		}
+		return nil, errDial
+	}, Retry(RetryPolicy{Attempts: 4, Delay: time.Millisecond, Jitter: 0.5}))
+	_, err = i.Invoke("*wheels.ServiceB")
+	var re *RetryError
+	assert.ErrorAs(t, err, &re)
+	assert.ErrorIs(t, err, errDial)
+	assert.Equal(t, 4, re.Attempts)
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, "name: *wheels.ServiceB, attempts: 4, err: dial failed", err.Error())
+
+	// not retryable
+	calls = 0
+	_ = i.Override(func() (*ServiceB, error) {
+		calls++
+		if calls == 1 {
+			return nil, errDial
+		}
+		return nil, ErrNewServiceF
+	}, Retry(RetryPolicy{Attempts: 5, Retryable: func(err error) bool {
+		return errors.Is(err, errDial)
+	}}))
+	_, err = i.Invoke("*wheels.ServiceB")
+	assert.ErrorAs(t, err, &re)
+	assert.ErrorIs(t, err, ErrNewServiceF)
+	assert.Equal(t, 2, re.Attempts)
+
+	// dependencies are not retried
+	calls = 0
+	_ = i.Override(func(c *ServiceC) *ServiceB {
+		calls++
+		return &ServiceB{c: c}
+	}, Retry(RetryPolicy{Attempts: 3}))
+	_, err = i.Invoke("*wheels.ServiceB")
+	assert.ErrorIs(t, err, ErrUnknownService)
+	assert.False(t, errors.As(err, &re))
+	assert.Equal(t, 0, calls)
+
+	err = i.ProvideZero(&ServiceC{}, Retry(RetryPolicy{Attempts: 3}))
+	assert.ErrorIs(t, err, ErrInvalidRetry)
+	err = i.Provide(NewServiceA, Retry(RetryPolicy{}))
+	assert.ErrorIs(t, err, ErrInvalidRetry)
+
+	// a single attempt is not wrapped
+	_ = i.Override(func() (*ServiceB, error) { return nil, errDial }, Retry(RetryPolicy{Attempts: 1}))
+	_, err = i.Invoke("*wheels.ServiceB")
+	assert.Equal(t, errDial, err)
+}
+
+func TestInjector_RetryCanceled(t *testing.T) {
+	i := New()
+	calls := 0
+	_ = i.Provide(func() (*ServiceA, error) {
+		calls++
+		return nil, errDial
+	}, Retry(RetryPolicy{Attempts: 3, Delay: time.Hour}))
+
+	start := time.Now()
+	_, err := i.Invoke("*wheels.ServiceA", WithTimeout(10*time.Millisecond))
+	assert.Less(t, time.Since(start), time.Minute)
+	var re *RetryError
+	assert.ErrorAs(t, err, &re)
+	assert.Equal(t, 1, re.Attempts)
+	assert.ErrorIs(t, err, errDial)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := &RetryPolicy{Delay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	assert.Equal(t, 10*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 20*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 40*time.Millisecond, p.backoff(3))
+	assert.Equal(t, 50*time.Millisecond, p.backoff(4))
+	assert.Equal(t, 50*time.Millisecond, p.backoff(100))
+
+	p = &RetryPolicy{Delay: 10 * time.Millisecond, Multiplier: 3, Jitter: 0.5}
+	assert.Equal(t, 90*time.Millisecond, (&RetryPolicy{Delay: p.Delay, Multiplier: 3}).backoff(3))
+	for j := 0; j < 100; j++ {
+		d := p.backoff(3)
+		assert.LessOrEqual(t, d, 90*time.Millisecond)
+		assert.Greater(t, d, 45*time.Millisecond-time.Nanosecond)
+	}
+}
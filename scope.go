@@ -0,0 +1,156 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Scope caches the services provided with Scoped for its name, e.g. one
+// scope per HTTP request. Services that are not scoped resolve as usual.
+type Scope struct {
+	name     string
+	injector *Injector
+
+	mu      sync.Mutex
+	values  map[Service]reflect.Value
+	calls   map[Service]*buildCall
+	entries []*lifecycleEntry
+	closed  bool
+}
+
+type scopeKey struct{}
+
+func withScope(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+func scopeFrom(ctx context.Context) *Scope {
+	s, _ := ctx.Value(scopeKey{}).(*Scope)
+	return s
+}
+
+// NewScope returns a new scope for the services provided with Scoped(name).
+func (i *Injector) NewScope(name string) *Scope {
+	return &Scope{
+		name:     name,
+		injector: i,
+		values:   map[Service]reflect.Value{},
+		calls:    map[Service]*buildCall{},
+	}
+}
+
+func (s *Scope) Name() string {
+	return s.name
+}
+
+func (s *Scope) Invoke(name string, opts ...InvokeOption) (any, error) {
+	return s.InvokeContext(context.Background(), name, opts...)
+}
+
+func (s *Scope) InvokeContext(ctx context.Context, name string, opts ...InvokeOption) (any, error) {
+	return s.injector.invoke(withScope(ctx, s), name, opts...)
+}
+
+// Close drops the services built in the scope. The ones that implement
+// Shutdowner or have OnShutdown hooks are shut down in reverse build order.
+func (s *Scope) Close(ctx context.Context) error {
+	s.mu.Lock()
+	entries := s.entries
+	s.values = nil
+	s.calls = nil
+	s.entries = nil
+	s.closed = true
+	s.mu.Unlock()
+	var errs []error
+	for j := len(entries) - 1; j >= 0; j-- {
+		errs = append(errs, entries[j].shutdown(ctx))
+	}
+	return joinErrors(errs...)
+}
+
+func (s *Scope) has(svc Service) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.values[svc]
+	return ok
+}
+
+func (s *Scope) drop(svc Service) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, svc)
+}
+
+// getScopedValue returns the value of svc cached in the scope of ctx,
+// building it on first use.
+func (i *Injector) getScopedValue(ctx context.Context, name string, svc Service, opts *providerOptions) (val reflect.Value, err error) {
+	sc := scopeFrom(ctx)
+	if sc == nil || sc.name != opts.Scope {
+		return val, fmt.Errorf("name: %v, scope: %v, err: %w", name, opts.Scope, ErrOutOfScope)
+	}
+	// a singleton would keep the scoped value after the scope is closed
+	for r := resolvingFrom(ctx).parent; r != nil; r = r.parent {
+		if r.opts.isSingleton() {
+			return val, fmt.Errorf("name: %v, singleton: %v, err: %w", name, r.name, ErrScopeMismatch)
+		}
+	}
+	sc.mu.Lock()
+	if sc.closed {
+		sc.mu.Unlock()
+		return val, fmt.Errorf("name: %v, scope: %v, err: %w", name, opts.Scope, ErrScopeClosed)
+	}
+	if val, ok := sc.values[svc]; ok {
+		sc.mu.Unlock()
+		return val, nil
+	}
+	if c := sc.calls[svc]; c != nil {
+		sc.mu.Unlock()
+		return c.wait(ctx, name)
+	}
+	call := newBuildCall(ctx, 0)
+	sc.calls[svc] = call
+	sc.mu.Unlock()
+
+	defer func() {
+		sc.finish(svc, call, name, opts, val, err)
+	}()
+	defer recoverBuild(ctx, name, &err)
+	return svc.newValue(ctx, i, name)
+}
+
+// finish caches the value built by call, unless the build failed or the
+// scope was closed meanwhile, and hands it to the invocations waiting.
+func (sc *Scope) finish(svc Service, call *buildCall, name string, opts *providerOptions, val reflect.Value, err error) {
+	sc.mu.Lock()
+	if sc.calls[svc] == call {
+		delete(sc.calls, svc)
+	}
+	if err == nil && !sc.closed {
+		sc.values[svc] = val
+		sc.entries = append(sc.entries, &lifecycleEntry{
+			name:       name,
+			instance:   val.Interface(),
+			onShutdown: opts.OnShutdown,
+		})
+	}
+	sc.mu.Unlock()
+	call.finish(val, err)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type scopedRequest struct {
+	id int
+}
+
+type scopedHandler struct {
+	Req *scopedRequest
+	A   *ServiceA
+}
+
+func TestInjector_Transient(t *testing.T) {
+	i := New()
+	_ = i.Provide(NewServiceA, Transient())
+	_ = i.ProvideZero(&ServiceD{}, Transient())
+	_ = i.ProvideZero(&ServiceC{})
+
+	a1, err := i.Invoke("*wheels.ServiceA")
+	assert.NoError(t, err)
+	a2, err := i.Invoke("*wheels.ServiceA")
+	assert.NoError(t, err)
+	assert.NotSame(t, a1, a2)
+
+	d1, err := i.Invoke("*wheels.ServiceD")
+	assert.NoError(t, err)
+	d2, err := i.Invoke("*wheels.ServiceD")
+	assert.NoError(t, err)
+	assert.NotSame(t, d1, d2)
+	assert.Same(t, d1.(*ServiceD).C, d2.(*ServiceD).C)
+	assert.NotSame(t, d1.(*ServiceD).A, d2.(*ServiceD).A)
+
+	err = i.ProvideInstance(&ServiceB{}, Transient())
+	assert.ErrorIs(t, err, ErrInvalidLifetime)
+}
+
+func TestInjector_Scope(t *testing.T) {
+	i := New()
+	ids := 0
+	_ = i.Provide(func() *scopedRequest {
+		ids++
+		return &scopedRequest{id: ids}
+	}, Scoped("request"), OnShutdown(func(ctx context.Context, ins any) error {
+		ins.(*scopedRequest).id = 0
+		return nil
+	}))
+	_ = i.ProvideZero(&scopedHandler{}, Scoped("request"))
+	_ = i.Provide(NewServiceA)
+	_ = i.Provide(func(r *scopedRequest) *ServiceB { return &ServiceB{} })
+
+	s1 := i.NewScope("request")
+	h1, err := s1.Invoke("*wheels.scopedHandler")
+	assert.NoError(t, err)
+	r1, err := s1.Invoke("*wheels.scopedRequest")
+	assert.NoError(t, err)
+	assert.Same(t, r1, h1.(*scopedHandler).Req)
+	assert.Equal(t, 1, r1.(*scopedRequest).id)
+
+	s2 := i.NewScope("request")
+	r2, err := s2.Invoke("*wheels.scopedRequest")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r2.(*scopedRequest).id)
+
+	a1, _ := s1.Invoke("*wheels.ServiceA")
+	a2, _ := s2.Invoke("*wheels.ServiceA")
+	assert.Same(t, a1, a2)
+
+	_, err = i.Invoke("*wheels.scopedRequest")
+	assert.ErrorIs(t, err, ErrOutOfScope)
+	_, err = i.NewScope("session").Invoke("*wheels.scopedRequest")
+	assert.ErrorIs(t, err, ErrOutOfScope)
+	_, err = s1.Invoke("*wheels.ServiceB")
+	assert.ErrorIs(t, err, ErrScopeMismatch)
+
+	assert.NoError(t, s1.Close(context.Background()))
+	assert.Equal(t, 0, r1.(*scopedRequest).id)
+	assert.Equal(t, 2, r2.(*scopedRequest).id)
+	_, err = s1.Invoke("*wheels.scopedRequest")
+	assert.ErrorIs(t, err, ErrScopeClosed)
+}
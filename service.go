@@ -16,12 +16,24 @@ http://www.apache.org/licenses/LICENSE-2.0
 
 package wheels
 
-import "reflect"
+import (
+	"context"
+	"reflect"
+)
 
 type Service interface {
 	getName() string
 	getType() reflect.Type
-	getValue(*Injector, string) (reflect.Value, error)
-	getInstance(*Injector, string) (any, error)
+	// getValue returns the value injected into the services that depend on
+	// the service, a zero service may not have its fields set yet.
+	getValue(context.Context, *Injector, string) (reflect.Value, error)
+	// buildValue returns the value with every field set.
+	buildValue(context.Context, *Injector, string) (reflect.Value, error)
+	newValue(context.Context, *Injector, string) (reflect.Value, error)
 	reset() bool
+	getParamNames() []string
+	getBuilt() (any, bool)
+	state() *buildState
+	save() savedState
+	restore(savedState)
 }
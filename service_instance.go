@@ -17,15 +17,17 @@ http://www.apache.org/licenses/LICENSE-2.0
 package wheels
 
 import (
+	"context"
 	"reflect"
 )
 
 type ServiceInstance struct {
-	name     string
-	typ      reflect.Type
-	instance any
-	value    reflect.Value
-	built    bool
+	name string
+	typ  reflect.Type
+	raw  reflect.Value
+
+	buildState
+	decorated bool
 }
 
 func newServiceInstance(name string, val any) Service {
@@ -33,16 +35,28 @@ func newServiceInstance(name string, val any) Service {
 	if name == "" {
 		name = rv.Type().String()
 	}
-	return &ServiceInstance{
-		name:     name,
-		instance: val,
-		typ:      rv.Type(),
-		value:    rv,
+	s := &ServiceInstance{
+		name: name,
+		typ:  rv.Type(),
+		raw:  rv,
 	}
+	s.value = rv
+	s.instance = val
+	return s
 }
 
+// reset drops the decorated instance, the provided one never changes. It
+// reports whether the instance was resolved, the services built from it are
+// reset then since a decorator may be added.
 func (s *ServiceInstance) reset() bool {
-	return false
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	built := s.resetLocked()
+	s.value = s.raw
+	s.instance = s.raw.Interface()
+	decorated := s.decorated
+	s.decorated = false
+	return built || decorated
 }
 
 func (s *ServiceInstance) getName() string {
@@ -53,14 +67,38 @@ func (s *ServiceInstance) getType() reflect.Type {
 	return s.typ
 }
 
-func (s *ServiceInstance) getInstance(i *Injector, insName string) (any, error) {
-	if !s.built {
-		i.setInstance(insName, s)
-		s.built = true
-	}
-	return s.instance, nil
+func (s *ServiceInstance) getBuilt() (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.instance, true
+}
+
+func (s *ServiceInstance) buildValue(ctx context.Context, i *Injector, insName string) (reflect.Value, error) {
+	return s.getValue(ctx, i, insName)
 }
 
-func (s *ServiceInstance) getValue(i *Injector, insName string) (val reflect.Value, err error) {
-	return s.value, nil
+func (s *ServiceInstance) newValue(ctx context.Context, i *Injector, insName string) (reflect.Value, error) {
+	return s.getValue(ctx, i, insName)
+}
+
+// getValue applies the decorators to the provided instance once.
+func (s *ServiceInstance) getValue(ctx context.Context, i *Injector, insName string) (val reflect.Value, err error) {
+	val, call, err := s.begin(ctx, insName)
+	if call == nil {
+		return val, err
+	}
+	var pnames []string
+	defer func() {
+		s.finish(i, s, insName, call, val, pnames, err)
+	}()
+	defer recoverBuild(ctx, insName, &err)
+	if i.hasDecorators(s.name) {
+		val, pnames, err = i.decorate(ctx, s.name, s.raw)
+		if err == nil {
+			s.mu.Lock()
+			s.decorated = s.gen == call.gen
+			s.mu.Unlock()
+		}
+	}
+	return val, err
 }
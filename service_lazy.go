@@ -17,23 +17,22 @@ http://www.apache.org/licenses/LICENSE-2.0
 package wheels
 
 import (
+	"context"
 	"fmt"
 	"reflect"
-	"sync"
 )
 
-var errType = reflect.TypeOf(new(error)).Elem()
+var (
+	errType = reflect.TypeOf(new(error)).Elem()
+	ctxType = reflect.TypeOf(new(context.Context)).Elem()
+)
 
 type ServiceLazy struct {
 	name string
 	typ  reflect.Type
 	ctor reflect.Value // func(...) (...vals, error) or func (...) vals
 
-	mu         sync.RWMutex
-	instance   any
-	value      reflect.Value
-	built      bool
-	paramNames []string
+	buildState
 }
 
 func newServiceLazy(name string, ctor any) (Service, error) {
@@ -63,12 +62,7 @@ func newServiceLazy(name string, ctor any) (Service, error) {
 func (s *ServiceLazy) reset() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if !s.built {
-		return false
-	}
-	s.built = false
-	s.paramNames = nil
-	return true
+	return s.resetLocked()
 }
 
 func (s *ServiceLazy) getType() reflect.Type {
@@ -79,62 +73,51 @@ func (s *ServiceLazy) getName() string {
 	return s.name
 }
 
-func (s *ServiceLazy) getInstance(i *Injector, insName string) (ins any, err error) {
+func (s *ServiceLazy) getBuilt() (any, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.built {
-		return s.instance, nil
-	}
-	err = s.buildInstanceLocked(i, insName)
-	if err != nil {
-		return nil, err
-	}
-	return s.instance, nil
+	return s.instance, s.built
+}
+
+func (s *ServiceLazy) buildValue(ctx context.Context, i *Injector, insName string) (reflect.Value, error) {
+	return s.getValue(ctx, i, insName)
 }
 
-// buildInstanceLocked TODO support ctx?
-func (s *ServiceLazy) buildInstanceLocked(i *Injector, insName string) (err error) {
-	ctype := s.ctor.Type()
-	paramValues := make([]reflect.Value, ctype.NumIn())
-	for j := 0; j < ctype.NumIn(); j++ {
-		ptype := ctype.In(j)
-		pname := ptype.String()
-		pvalue, err := i.getValueLocked(pname)
-		if err != nil {
-			return err
-		}
-		paramValues[j] = pvalue
-		s.paramNames = append(s.paramNames, pname)
-		i.appendAssociatedService(pname, s)
+// construct calls the ctor and returns the names of the services the value
+// was built from.
+func (s *ServiceLazy) construct(ctx context.Context, i *Injector, insName string) (val reflect.Value, pnames []string, err error) {
+	// the options are released once an override replaced the service
+	// under all its names
+	if opts := i.optionsOf(s); opts != nil && opts.Retry != nil {
+		val, pnames, err = opts.Retry.call(ctx, i, insName, s.ctor)
+	} else {
+		val, pnames, err = i.call(ctx, insName, s.ctor)
 	}
-	retValues := s.ctor.Call(paramValues)
-	if len(retValues) == 2 {
-		errValue := retValues[1]
-		if errValue.IsNil() {
-			err = nil
-		} else {
-			err = errValue.Interface().(error)
-		}
+	if err != nil {
+		return val, nil, err
 	}
+	val, dnames, err := i.decorate(ctx, s.name, val)
 	if err != nil {
-		return
+		return val, nil, err
 	}
-	s.instance = retValues[0].Interface()
-	s.value = retValues[0]
-	s.built = true
-	i.setInstance(insName, s.instance)
-	return nil
+	return val, append(pnames, dnames...), nil
 }
 
-func (s *ServiceLazy) getValue(i *Injector, insName string) (val reflect.Value, err error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if s.built {
-		return s.value, nil
-	}
-	err = s.buildInstanceLocked(i, insName)
-	if err != nil {
+func (s *ServiceLazy) newValue(ctx context.Context, i *Injector, insName string) (reflect.Value, error) {
+	val, _, err := s.construct(ctx, i, insName)
+	return val, err
+}
+
+func (s *ServiceLazy) getValue(ctx context.Context, i *Injector, insName string) (val reflect.Value, err error) {
+	val, call, err := s.begin(ctx, insName)
+	if call == nil {
 		return val, err
 	}
-	return s.value, nil
+	var pnames []string
+	defer func() {
+		s.finish(i, s, insName, call, val, pnames, err)
+	}()
+	defer recoverBuild(ctx, insName, &err)
+	val, pnames, err = s.construct(ctx, i, insName)
+	return val, err
 }
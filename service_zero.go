@@ -17,20 +17,16 @@ http://www.apache.org/licenses/LICENSE-2.0
 package wheels
 
 import (
+	"context"
 	"fmt"
 	"reflect"
-	"sync"
 )
 
 type ServiceZero struct {
 	typ  reflect.Type
 	name string
 
-	mu         sync.Mutex
-	built      bool
-	paramNames []string
-	value      reflect.Value
-	instance   any
+	buildState
 }
 
 func newServiceZero(name string, val any) (Service, error) {
@@ -50,17 +46,16 @@ func newServiceZero(name string, val any) (Service, error) {
 	return s, nil
 }
 
+// reset also allocates a new value unless it was not handed out yet.
 func (s *ServiceZero) reset() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if !s.built {
-		return false
+	building := s.call != nil
+	isReset := s.resetLocked()
+	if isReset || building {
+		s.init()
 	}
-	s.built = false
-	s.paramNames = nil
-	s.value = reflect.Value{}
-	s.init()
-	return true
+	return isReset
 }
 
 func (s *ServiceZero) getName() string {
@@ -71,17 +66,33 @@ func (s *ServiceZero) getType() reflect.Type {
 	return s.typ
 }
 
-func (s *ServiceZero) getInstance(i *Injector, insName string) (ins any, err error) {
+func (s *ServiceZero) getBuilt() (any, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.built {
-		return s.instance, nil
-	}
-	err = s.buildInstanceLocked(i, insName)
-	if err != nil {
-		return
+	return s.instance, s.built
+}
+
+// allocated returns the value allocated for the service, which may not be
+// built yet.
+func (s *ServiceZero) allocated() reflect.Value {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value
+}
+
+// buildValue sets the fields of the allocated value, unless it is built.
+func (s *ServiceZero) buildValue(ctx context.Context, i *Injector, insName string) (val reflect.Value, err error) {
+	val, call, err := s.begin(ctx, insName)
+	if call == nil {
+		return val, err
 	}
-	return s.instance, nil
+	var pnames []string
+	defer func() {
+		s.finish(i, s, insName, call, val, pnames, err)
+	}()
+	defer recoverBuild(ctx, insName, &err)
+	val, pnames, err = s.build(ctx, i, insName, val)
+	return val, err
 }
 
 func (s *ServiceZero) init() {
@@ -94,8 +105,27 @@ func (s *ServiceZero) init() {
 	s.instance = s.value.Interface()
 }
 
-func (s *ServiceZero) buildInstanceLocked(i *Injector, insName string) (err error) {
-	val := s.value
+// build sets the fields of val and decorates it.
+func (s *ServiceZero) build(ctx context.Context, i *Injector, insName string, val reflect.Value) (reflect.Value, []string, error) {
+	pnames, err := s.inject(ctx, i, insName, val)
+	if err != nil {
+		return val, nil, err
+	}
+	// services that got the zero value early in a circular dependency keep
+	// the undecorated value
+	val, dnames, err := i.decorate(ctx, s.name, val)
+	if err != nil {
+		return val, nil, err
+	}
+	return val, append(pnames, dnames...), nil
+}
+
+// inject sets the settable fields of val and returns the names of the
+// services injected.
+func (s *ServiceZero) inject(ctx context.Context, i *Injector, insName string, val reflect.Value) (pnames []string, err error) {
+	if ctx.Err() != nil {
+		return nil, newTimeoutError(insName, ctx.Err())
+	}
 	if s.typ.Kind() == reflect.Ptr {
 		val = val.Elem()
 	}
@@ -105,25 +135,35 @@ func (s *ServiceZero) buildInstanceLocked(i *Injector, insName string) (err erro
 		if !fe.CanSet() {
 			continue
 		}
-		pname := fe.Type().String()
-		param, err := i.getValueLocked(pname)
+		field := val.Type().Field(j)
+		param, pname, ok, err := i.getFieldValue(ctx, field)
 		if err != nil {
-			return err
+			return nil, newResolutionError(ctx, pname, -1, field.Name, err)
 		}
-		fe.Set(param)
-		s.paramNames = append(s.paramNames, pname)
-		i.appendAssociatedService(pname, s)
+		if ok {
+			fe.Set(param)
+		}
+		pnames = append(pnames, pname)
 	}
-	s.built = true
-	i.setInstance(insName, s.instance)
-	return
+	return pnames, nil
+}
+
+func (s *ServiceZero) newValue(ctx context.Context, i *Injector, insName string) (reflect.Value, error) {
+	val := reflect.New(s.typ.Elem())
+	val, _, err := s.build(ctx, i, insName, val)
+	return val, err
 }
 
-func (s *ServiceZero) getValue(i *Injector, insName string) (reflect.Value, error) {
+func (s *ServiceZero) getValue(ctx context.Context, i *Injector, insName string) (reflect.Value, error) {
+	// a decorated value only exists once the fields are set
+	if i.hasDecorators(s.name) {
+		return s.buildValue(ctx, i, insName)
+	}
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	if !s.built {
-		i.setEarlyService(s)
+	val, built := s.value, s.built
+	s.mu.Unlock()
+	if !built {
+		invokeStateFrom(ctx).setEarly(i, s)
 	}
-	return s.value, nil
+	return val, nil
 }
@@ -0,0 +1,199 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"reflect"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// Snapshot holds the services registered in an Injector and the instances
+// built, as they were when Injector.Snapshot was called.
+type Snapshot struct {
+	injector           *Injector
+	services           map[string]Service
+	serviceInstances   map[Service][]string
+	earlyServices      map[string]Service
+	associatedServices map[string][]Service
+	serviceOptions     map[Service]*providerOptions
+	groups             map[string][]Service
+	decorators         map[string][]reflect.Value
+	instances          map[string]any
+	states             map[Service]savedState
+	parentDeps         map[Service][]parentDep
+}
+
+// savedState is the build state of a service in a Snapshot.
+type savedState struct {
+	built      bool
+	instance   any
+	value      reflect.Value
+	paramNames []string
+	proxies    map[string]reflect.Value
+	decorated  bool
+}
+
+// Snapshot saves the services of i, so that Restore can undo the Provide,
+// Override and Decorate calls made since and bring back the instances that
+// were built. The state of the instances themselves is not saved.
+func (i *Injector) Snapshot() *Snapshot {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.snapshotLocked()
+}
+
+func (i *Injector) snapshotLocked() *Snapshot {
+	s := &Snapshot{
+		injector:           i,
+		services:           maps.Clone(i.services),
+		serviceInstances:   cloneServiceInstances(i.serviceInstances),
+		earlyServices:      maps.Clone(i.earlyServices),
+		associatedServices: cloneSlices(i.associatedServices),
+		serviceOptions:     cloneServiceOptions(i.serviceOptions),
+		groups:             cloneSlices(i.groups),
+		decorators:         cloneSlices(i.decorators),
+		instances:          map[string]any{},
+		states:             map[Service]savedState{},
+		parentDeps:         cloneParentDeps(i.parentDeps),
+	}
+	i.instances.Range(func(k, v any) bool {
+		s.instances[k.(string)] = v
+		return true
+	})
+	for _, svc := range i.services {
+		if _, ok := s.states[svc]; !ok {
+			s.states[svc] = svc.save()
+		}
+	}
+	return s
+}
+
+// Restore brings the injector back to the snapshot. Builds in progress are
+// dropped, the watchers are not notified and the services started since
+// are not stopped. A snapshot can be restored more than once.
+func (s *Snapshot) Restore() {
+	i := s.injector
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	// the builds in progress of the services provided since are dropped,
+	// finishing them does not touch the restored registry
+	for _, svc := range i.services {
+		if _, ok := s.states[svc]; !ok {
+			svc.reset()
+		}
+	}
+	i.services = maps.Clone(s.services)
+	i.serviceInstances = cloneServiceInstances(s.serviceInstances)
+	i.earlyServices = maps.Clone(s.earlyServices)
+	i.associatedServices = cloneSlices(s.associatedServices)
+	i.serviceOptions = cloneServiceOptions(s.serviceOptions)
+	i.groups = cloneSlices(s.groups)
+	i.decorators = cloneSlices(s.decorators)
+	i.parentDeps = cloneParentDeps(s.parentDeps)
+	// the services restored are checked against the parents again
+	i.synced.Store(0)
+	i.rebuilds = nil
+	for svc, state := range s.states {
+		svc.restore(state)
+	}
+	i.instances.Range(func(k, _ any) bool {
+		i.instances.Delete(k)
+		return true
+	})
+	for name, ins := range s.instances {
+		i.setInstance(name, ins)
+	}
+	i.typed.clear()
+}
+
+func (b *buildState) save() savedState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return savedState{
+		built:      b.built,
+		instance:   b.instance,
+		value:      b.value,
+		paramNames: b.paramNames,
+		proxies:    maps.Clone(b.proxies),
+	}
+}
+
+// restore sets the saved state, a build in progress is dropped.
+func (b *buildState) restore(state savedState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gen++
+	b.call = nil
+	b.built = state.built
+	b.instance = state.instance
+	b.value = state.value
+	b.paramNames = state.paramNames
+	b.proxies = maps.Clone(state.proxies)
+}
+
+func (s *ServiceInstance) save() savedState {
+	state := s.buildState.save()
+	s.mu.Lock()
+	state.decorated = s.decorated
+	s.mu.Unlock()
+	return state
+}
+
+func (s *ServiceInstance) restore(state savedState) {
+	s.buildState.restore(state)
+	s.mu.Lock()
+	s.decorated = state.decorated
+	s.mu.Unlock()
+}
+
+// cloneSlices copies m and its slices, which are modified in place.
+func cloneSlices[K comparable, V any](m map[K][]V) map[K][]V {
+	c := make(map[K][]V, len(m))
+	for k, v := range m {
+		c[k] = slices.Clone(v)
+	}
+	return c
+}
+
+// The maps keyed by Service are copied by hand, interfaces only satisfy
+// comparable from go1.20.
+
+func cloneServiceInstances(m map[Service][]string) map[Service][]string {
+	c := make(map[Service][]string, len(m))
+	for k, v := range m {
+		c[k] = slices.Clone(v)
+	}
+	return c
+}
+
+func cloneParentDeps(m map[Service][]parentDep) map[Service][]parentDep {
+	c := make(map[Service][]parentDep, len(m))
+	for k, v := range m {
+		c[k] = slices.Clone(v)
+	}
+	return c
+}
+
+func cloneServiceOptions(m map[Service]*providerOptions) map[Service]*providerOptions {
+	c := make(map[Service]*providerOptions, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
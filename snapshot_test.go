@@ -0,0 +1,58 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot_RestoreDuringBuild(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	snap := i.Snapshot()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	_ = i.Provide(func(a *ServiceA) *ServiceB {
+		close(started)
+		<-release
+		return &ServiceB{a: a}
+	}, As(new(ServiceTest)))
+	_ = i.Provide(newServiceJ)
+	done := make(chan error)
+	go func() {
+		_, err := i.Invoke("*wheels.ServiceJ")
+		done <- err
+	}()
+	<-started
+	snap.Restore()
+	close(release)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("build blocked by the restore")
+	}
+
+	_, err := i.Invoke("*wheels.ServiceJ")
+	assert.ErrorIs(t, err, ErrUnknownService)
+	_, ok := i.getInstance("*wheels.ServiceB")
+	assert.False(t, ok)
+	assert.Len(t, i.serviceOptions, 1)
+}
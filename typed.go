@@ -0,0 +1,85 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"context"
+	"fmt"
+)
+
+// typeName returns the name of the services of type T, which also works for
+// interfaces unlike %T.
+func typeName[T any]() string {
+	return typeOf[T]().String()
+}
+
+// ProvideFunc provides the service of type T built by ctor, whose first
+// result must be assignable to T. The service is named after T, so a ctor
+// returning an implementation can provide an interface.
+func ProvideFunc[T any](i *Injector, ctor any, opts ...ProvideOption) error {
+	options := &providerOptions{Name: typeName[T]()}
+	for _, po := range opts {
+		po(options)
+	}
+	svc, err := newServiceLazy(options.Name, ctor)
+	if err != nil {
+		return err
+	}
+	typ := typeOf[T]()
+	if !svc.getType().AssignableTo(typ) {
+		return fmt.Errorf("name: %v, err: %w", options.Name, ErrInvalidCtorType)
+	}
+	svc.(*ServiceLazy).typ = typ
+	return i.provide(svc, options)
+}
+
+// ProvideValue provides val as the service of type T.
+func ProvideValue[T any](i *Injector, val T, opts ...ProvideOption) error {
+	return i.ProvideInstance(val, append([]ProvideOption{Name(typeName[T]())}, opts...)...)
+}
+
+// InvokeFrom returns the service of type T from i.
+func InvokeFrom[T any](i *Injector, opts ...InvokeOption) (T, error) {
+	return invokeTyped[T](context.Background(), i, opts)
+}
+
+// MustInvoke is like InvokeFrom, but panics if the service cannot be built.
+func MustInvoke[T any](i *Injector, opts ...InvokeOption) T {
+	ins, err := InvokeFrom[T](i, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return ins
+}
+
+// InvokeNamed returns the service name from i as a T.
+func InvokeNamed[T any](i *Injector, name string, opts ...InvokeOption) (T, error) {
+	return InvokeNamedContext[T](context.Background(), i, name, opts...)
+}
+
+// InvokeNamedContext is like InvokeNamed, but passes ctx to the ctors.
+func InvokeNamedContext[T any](ctx context.Context, i *Injector, name string, opts ...InvokeOption) (ins T, err error) {
+	val, err := i.InvokeContext(ctx, name, opts...)
+	if err != nil {
+		return
+	}
+	ins, ok := val.(T)
+	if !ok {
+		return ins, fmt.Errorf("name: %v, err: %w", name, ErrInvalidInvokeType)
+	}
+	return
+}
@@ -0,0 +1,58 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvideFunc(t *testing.T) {
+	i := New()
+	err := ProvideValue(i, &ServiceA{val: 1})
+	assert.NoError(t, err)
+	err = ProvideFunc[ServiceTest](i, func(a *ServiceA) *ServiceJ { return &ServiceJ{s: a} })
+	assert.ErrorIs(t, err, ErrInvalidCtorType)
+	err = ProvideFunc[ServiceTest](i, func(a *ServiceA) (*ServiceA, error) { return a, nil })
+	assert.NoError(t, err)
+	err = ProvideFunc[*ServiceJ](i, newServiceJ)
+	assert.NoError(t, err)
+
+	a, err := InvokeFrom[*ServiceA](i)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, a.val)
+	s, err := InvokeFrom[ServiceTest](i)
+	assert.NoError(t, err)
+	assert.Same(t, a, s)
+	assert.Same(t, a, MustInvoke[*ServiceJ](i).s)
+
+	_, err = InvokeFrom[*ServiceB](i)
+	assert.ErrorIs(t, err, ErrUnknownService)
+	assert.Panics(t, func() { MustInvoke[*ServiceB](i) })
+}
+
+func TestInvokeNamed(t *testing.T) {
+	i := New()
+	_ = ProvideValue[ServiceTest](i, &ServiceA{val: 2}, Name("a"))
+
+	s, err := InvokeNamed[ServiceTest](i, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "A", s.Print())
+	_, err = InvokeNamed[*ServiceB](i, "a")
+	assert.ErrorIs(t, err, ErrInvalidInvokeType)
+}
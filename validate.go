@@ -0,0 +1,146 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Validate checks the wiring of every registered service without building
+// any of them. Each dependency declared by a ctor, a field of a zero service
+// or a decorator must be provided, and a cycle of dependencies must go
+// through a zero service, which can be handed out before its fields are set.
+// All the problems found are returned in a MultiError.
+func (i *Injector) Validate() error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	var errs MultiError
+	svcs := i.registeredServicesLocked()
+	for _, svc := range svcs {
+		for _, dep := range i.declaredDependenciesLocked(svc) {
+			if err := i.validateDependencyLocked(dep.dependency); err != nil {
+				errs = append(errs, fmt.Errorf("name: %v, %w", svc.getName(), err))
+			}
+		}
+	}
+	errs = append(errs, i.validateCyclesLocked(svcs)...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func (i *Injector) validateDependencyLocked(dep dependency) error {
+	switch {
+	case dep.group:
+		if dep.typ.Kind() != reflect.Slice {
+			return fmt.Errorf("group: %v, type: %v, err: %w", dep.name, dep.typ.String(), ErrInvalidGroupType)
+		}
+	case !dep.optional && !i.hasServiceLocked(dep.name):
+		return fmt.Errorf("dependency: %v, err: %w", dep.name, ErrUnknownService)
+	}
+	return nil
+}
+
+// validateCyclesLocked returns an error for every cycle between svcs that
+// does not go through a singleton zero service.
+func (i *Injector) validateCyclesLocked(svcs []Service) []error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[Service]int{}
+	seen := map[string]bool{}
+	var stack []Service
+	var errs []error
+	var visit func(svc Service)
+	visit = func(svc Service) {
+		state[svc] = visiting
+		stack = append(stack, svc)
+		for _, dep := range i.dependencyServicesLocked(svc) {
+			if i.breaksCycleLocked(dep) {
+				continue
+			}
+			switch state[dep] {
+			case visiting:
+				if key, err := i.cycleErrorLocked(stack, dep); !seen[key] {
+					seen[key] = true
+					errs = append(errs, err)
+				}
+			case 0:
+				visit(dep)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[svc] = done
+	}
+	for _, svc := range svcs {
+		if state[svc] == 0 && !i.breaksCycleLocked(svc) {
+			visit(svc)
+		}
+	}
+	return errs
+}
+
+// dependencyServicesLocked returns the services of i svc declares a
+// dependency on.
+func (i *Injector) dependencyServicesLocked(svc Service) []Service {
+	var deps []Service
+	for _, dep := range i.declaredDependenciesLocked(svc) {
+		if dep.group {
+			deps = append(deps, i.groups[dep.name]...)
+		} else if s, ok := i.services[dep.name]; ok {
+			deps = append(deps, s)
+		}
+	}
+	return deps
+}
+
+// breaksCycleLocked reports whether svc is handed out while it is being
+// built when it is resolved again.
+func (i *Injector) breaksCycleLocked(svc Service) bool {
+	_, ok := svc.(*ServiceZero)
+	return ok && i.serviceOptions[svc].isSingleton()
+}
+
+// cycleErrorLocked returns a key identifying the cycle from svc to the top of
+// the stack and back, whatever service it is entered from, and its error.
+func (i *Injector) cycleErrorLocked(stack []Service, svc Service) (string, error) {
+	start := len(stack) - 1
+	for stack[start] != svc {
+		start--
+	}
+	cycle := stack[start:]
+	first := 0
+	for j, s := range cycle {
+		if s.getName() < cycle[first].getName() {
+			first = j
+		}
+	}
+	var names, candidates []string
+	for j := range cycle {
+		s := cycle[(first+j)%len(cycle)]
+		names = append(names, s.getName())
+		if _, ok := s.(*ServiceLazy); ok && isZeroCandidate(s.getType()) {
+			candidates = append(candidates, s.getName())
+		}
+	}
+	key := fmt.Sprint(names)
+	names = append(names, names[0])
+	return key, circularDependencyError(names, candidates)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjector_Validate(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{})
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	_ = i.Provide(newServiceJ)
+	_ = i.ProvideZero(&cycleZ{})
+	_ = i.Provide(func(z *cycleZ) *cycleX { return &cycleX{z: z} })
+	assert.NoError(t, i.Validate())
+
+	_ = i.ProvideZero(&ServiceG{})
+	_ = i.Provide(func(b *cycleB) *cycleA { return &cycleA{b: b} })
+	_ = i.Provide(func(a *cycleA, o Optional[*ServiceF]) *cycleB { return &cycleB{a: a} })
+	_ = i.Decorate(func(j *ServiceJ, h *ServiceH) *ServiceJ { return j })
+
+	err := i.Validate()
+	var errs MultiError
+	assert.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 3)
+	assert.ErrorIs(t, errs[0], ErrUnknownService)
+	assert.Contains(t, errs[0].Error(), "name: *wheels.ServiceG, dependency: *wheels.ServiceF")
+	assert.ErrorIs(t, errs[1], ErrUnknownService)
+	assert.Contains(t, errs[1].Error(), "name: *wheels.ServiceJ, dependency: *wheels.ServiceH")
+	assert.ErrorIs(t, errs[2], ErrCircularDependency)
+	assert.Contains(t, errs[2].Error(), "*wheels.cycleA -> *wheels.cycleB -> *wheels.cycleA")
+
+	// nothing was built
+	_, built := i.services["*wheels.ServiceB"].getBuilt()
+	assert.False(t, built)
+}
+
+func TestInjector_ValidateChild(t *testing.T) {
+	parent := New()
+	_ = parent.ProvideInstance(&ServiceA{})
+	child := parent.Child()
+	_ = child.Provide(func(a *ServiceA) *ServiceF { return &ServiceF{} })
+	_ = child.ProvideZero(&ServiceG{})
+	assert.NoError(t, child.Validate())
+}
+
+type validateTags struct {
+	Tags []string
+}
+
+func TestInjector_ValidateSlice(t *testing.T) {
+	i := New()
+	_ = i.ProvideZero(&validateTags{})
+	err := i.Validate()
+	assert.ErrorIs(t, err, ErrUnknownService)
+	_, err = i.Invoke("*wheels.validateTags")
+	assert.ErrorIs(t, err, ErrUnknownService)
+}
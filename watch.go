@@ -0,0 +1,155 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+// RebuildEvent is sent to the watchers of a service after it was rebuilt
+// because it, or one of the services it depends on, was overridden.
+type RebuildEvent struct {
+	Name string
+	Old  any
+	New  any
+	// Err is set if the service failed to rebuild, New is nil then.
+	Err error
+}
+
+const watchBuffer = 16
+
+type watcher struct {
+	mu     sync.Mutex
+	notify func(RebuildEvent)
+	closed bool
+}
+
+// send calls notify without holding w.mu, so that it may cancel the watch.
+func (w *watcher) send(ev RebuildEvent) {
+	w.mu.Lock()
+	closed := w.closed
+	w.mu.Unlock()
+	if !closed {
+		w.notify(ev)
+	}
+}
+
+type rebuild struct {
+	name string
+	old  any
+}
+
+// OnRebuild calls fn with the old and the new instance every time the
+// service name is rebuilt after an override reset it or one of its
+// transitive dependencies. Watched services are rebuilt right after the
+// override, in the goroutine that made it; no call is made if the rebuild
+// fails. The returned func stops the calls, it may be called from fn.
+func (i *Injector) OnRebuild(name string, fn func(old, new any)) (cancel func()) {
+	return i.watch(name, func(ev RebuildEvent) {
+		if ev.Err == nil {
+			fn(ev.Old, ev.New)
+		}
+	}, nil)
+}
+
+// Watch is like OnRebuild, but sends the events to the returned channel. If
+// the receiver falls behind, the oldest pending events are dropped. The
+// returned func stops the events and closes the channel.
+func (i *Injector) Watch(name string) (<-chan RebuildEvent, func()) {
+	ch := make(chan RebuildEvent, watchBuffer)
+	// guards sending on ch against closing it
+	var (
+		mu     sync.Mutex
+		closed bool
+	)
+	cancel := i.watch(name, func(ev RebuildEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		for !closed {
+			select {
+			case ch <- ev:
+				return
+			default:
+			}
+			select {
+			case <-ch:
+			default:
+			}
+		}
+	}, func() {
+		mu.Lock()
+		closed = true
+		close(ch)
+		mu.Unlock()
+	})
+	return ch, cancel
+}
+
+func (i *Injector) watch(name string, notify func(RebuildEvent), onCancel func()) func() {
+	w := &watcher{notify: notify}
+	i.mu.Lock()
+	i.watchers[name] = append(i.watchers[name], w)
+	i.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			i.mu.Lock()
+			i.watchers[name] = slices.DeleteFunc(i.watchers[name], func(v *watcher) bool { return v == w })
+			i.mu.Unlock()
+			w.mu.Lock()
+			w.closed = true
+			w.mu.Unlock()
+			if onCancel != nil {
+				onCancel()
+			}
+		})
+	}
+}
+
+// recordRebuildLocked remembers the old instance of the service name when it
+// is reset, if anyone watches name.
+func (i *Injector) recordRebuildLocked(name string, old any) {
+	if len(i.watchers[name]) == 0 {
+		return
+	}
+	for _, r := range i.rebuilds {
+		if r.name == name {
+			return
+		}
+	}
+	i.rebuilds = append(i.rebuilds, rebuild{name: name, old: old})
+}
+
+// notifyRebuilds rebuilds the watched services that were reset and notifies
+// their watchers, it must be called without holding i.mu.
+func (i *Injector) notifyRebuilds() {
+	i.mu.Lock()
+	rebuilds := i.rebuilds
+	i.rebuilds = nil
+	i.mu.Unlock()
+	for _, r := range rebuilds {
+		ins, err := i.Invoke(r.name)
+		i.mu.RLock()
+		watchers := slices.Clone(i.watchers[r.name])
+		i.mu.RUnlock()
+		for _, w := range watchers {
+			w.send(RebuildEvent{Name: r.name, Old: r.old, New: ins, Err: err})
+		}
+	}
+}
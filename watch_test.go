@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheels
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjector_OnRebuild(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{val: 1})
+	_ = i.Provide(NewServiceB, As(new(ServiceTest)))
+	_ = i.ProvideZero(&ServiceC{})
+	_ = i.ProvideZero(&ServiceD{})
+	_ = i.Provide(newServiceJ)
+
+	j, err := i.Invoke("*wheels.ServiceJ")
+	assert.NoError(t, err)
+	a, _ := i.Invoke("*wheels.ServiceA")
+
+	var events [][2]any
+	cancel := i.OnRebuild("*wheels.ServiceJ", func(old, new any) {
+		events = append(events, [2]any{old, new})
+	})
+	var aEvents int
+	cancelA := i.OnRebuild("*wheels.ServiceA", func(old, new any) {
+		assert.Same(t, a, old)
+		assert.Equal(t, 2, new.(*ServiceA).val)
+		aEvents++
+	})
+	ch, stop := i.Watch("*wheels.ServiceJ")
+
+	err = i.OverrideInstance(&ServiceA{val: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, aEvents)
+	assert.Len(t, events, 1)
+	assert.Same(t, j, events[0][0])
+	nj, _ := i.Invoke("*wheels.ServiceJ")
+	assert.Same(t, nj, events[0][1])
+	assert.NotSame(t, j, nj)
+	ev := <-ch
+	assert.Equal(t, "*wheels.ServiceJ", ev.Name)
+	assert.Same(t, nj, ev.New)
+
+	cancel()
+	cancelA()
+	stop()
+	_, open := <-ch
+	assert.False(t, open)
+	err = i.Override(NewServiceA, As(new(ServiceTest)))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestInjector_OnRebuildCancelInCallback(t *testing.T) {
+	i := New()
+	_ = i.ProvideInstance(&ServiceA{val: 1})
+	_, _ = i.Invoke("*wheels.ServiceA")
+
+	calls := 0
+	var cancel func()
+	cancel = i.OnRebuild("*wheels.ServiceA", func(old, new any) {
+		calls++
+		cancel()
+	})
+	ch, stop := i.Watch("*wheels.ServiceA")
+
+	done := make(chan struct{})
+	go func() {
+		_ = i.OverrideInstance(&ServiceA{val: 2})
+		_ = i.OverrideInstance(&ServiceA{val: 3})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("override blocked by the canceled watcher")
+	}
+	assert.Equal(t, 1, calls)
+
+	stop()
+	var events []RebuildEvent
+	for ev := range ch {
+		events = append(events, ev)
+	}
+	assert.Len(t, events, 2)
+}
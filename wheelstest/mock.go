@@ -0,0 +1,292 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheelstest
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/rame2015/wheels"
+)
+
+// Any matches any argument passed to Mock.On and the call assertions.
+var Any any = anyArg{}
+
+type anyArg struct{}
+
+// Call is a method call recorded by a Mock. A variadic argument is recorded
+// as a single slice.
+type Call struct {
+	Method  string
+	Args    []any
+	Results []any
+}
+
+// Mock is a recording stub of an interface, made from the proxy registered
+// for the interface with wheels.RegisterProxy, e.g. generated by
+// cmd/wheels-proxy. A call returns the results of the first stub set with
+// On that matches it, or the zero values of the results.
+type Mock struct {
+	iface reflect.Type
+	stub  any
+
+	mu    sync.Mutex
+	stubs []*Stub
+	calls []Call
+}
+
+// Stub sets the results of the calls of a method.
+type Stub struct {
+	mock    *Mock
+	method  string
+	args    []any
+	results []any
+	fn      func(args ...any) []any
+	times   int
+	used    int
+}
+
+// NewMock returns a stub implementing the interface T and the Mock that
+// records its calls. It fails t if no proxy is registered for T.
+func NewMock[T any](t testing.TB) (T, *Mock) {
+	t.Helper()
+	var stub T
+	m, err := newMock(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		t.Fatalf("mock: %v", err)
+		return stub, nil
+	}
+	stub, _ = m.stub.(T)
+	return stub, m
+}
+
+func newMock(iface reflect.Type) (*Mock, error) {
+	m := &Mock{iface: iface}
+	stub, err := wheels.MakeProxy(iface, m.handle)
+	if err != nil {
+		return nil, err
+	}
+	m.stub = stub
+	return m, nil
+}
+
+// InjectMock overrides the service named after the interface T in inj with
+// a new mock of T until t completes, and returns the mock.
+func InjectMock[T any](t testing.TB, inj *wheels.Injector) *Mock {
+	t.Helper()
+	stub, m := NewMock[T](t)
+	Isolate(t, inj)
+	if err := inj.OverrideInstance(stub, wheels.Name(m.iface.String())); err != nil {
+		t.Fatalf("mock: %v", err)
+	}
+	return m
+}
+
+// MockDependencies overrides every interface the service name of inj
+// depends on with a new mock until t completes, so that the service can be
+// tested on its own. The mocks are returned by the name of the service they
+// replace. Optional dependencies and groups are left as is.
+func MockDependencies(t testing.TB, inj *wheels.Injector, name string) map[string]*Mock {
+	t.Helper()
+	deps, err := inj.Dependencies(name)
+	if err != nil {
+		t.Fatalf("mock: %v", err)
+		return nil
+	}
+	Isolate(t, inj)
+	mocks := map[string]*Mock{}
+	for _, dep := range deps {
+		if dep.Group || dep.Optional || dep.Type.Kind() != reflect.Interface || mocks[dep.Name] != nil {
+			continue
+		}
+		m, err := newMock(dep.Type)
+		if err == nil {
+			err = inj.OverrideInstance(m.stub, wheels.Name(dep.Name))
+		}
+		if err != nil {
+			t.Fatalf("mock: name: %v, err: %v", dep.Name, err)
+			return nil
+		}
+		mocks[dep.Name] = m
+	}
+	return mocks
+}
+
+// Stub returns the value implementing the interface of m.
+func (m *Mock) Stub() any {
+	return m.stub
+}
+
+// On sets the results of the calls of method with args, or with any args if
+// none are passed. Args are compared with reflect.DeepEqual, Any matches
+// every value. It panics if the interface has no such method.
+func (m *Mock) On(method string, args ...any) *Stub {
+	if _, ok := m.iface.MethodByName(method); !ok {
+		panic(fmt.Sprintf("mock: %v has no method %v", m.iface, method))
+	}
+	s := &Stub{mock: m, method: method, args: args}
+	m.mu.Lock()
+	m.stubs = append(m.stubs, s)
+	m.mu.Unlock()
+	return s
+}
+
+// Return sets the results of the calls, nil is the zero value of a result.
+func (s *Stub) Return(results ...any) *Stub {
+	s.mock.mu.Lock()
+	defer s.mock.mu.Unlock()
+	s.results = results
+	return s
+}
+
+// Do calls fn with the args of the calls instead, it returns the results.
+func (s *Stub) Do(fn func(args ...any) []any) *Stub {
+	s.mock.mu.Lock()
+	defer s.mock.mu.Unlock()
+	s.fn = fn
+	return s
+}
+
+// Times limits the stub to the next n calls, the following calls match the
+// stubs set after it.
+func (s *Stub) Times(n int) *Stub {
+	s.mock.mu.Lock()
+	defer s.mock.mu.Unlock()
+	s.times = n
+	return s
+}
+
+func (m *Mock) handle(method string, args ...any) []any {
+	m.mu.Lock()
+	var results []any
+	var fn func(args ...any) []any
+	for _, s := range m.stubs {
+		if s.method != method || (s.times > 0 && s.used >= s.times) || !matchArgs(s.args, args) {
+			continue
+		}
+		s.used++
+		results, fn = s.results, s.fn
+		break
+	}
+	m.mu.Unlock()
+	if fn != nil {
+		results = fn(args...)
+	}
+	results = m.convertResults(method, results)
+	m.mu.Lock()
+	m.calls = append(m.calls, Call{Method: method, Args: args, Results: results})
+	m.mu.Unlock()
+	return results
+}
+
+// convertResults returns results as the types of the results of method,
+// missing or nil ones are zero values.
+func (m *Mock) convertResults(method string, results []any) []any {
+	mt, _ := m.iface.MethodByName(method)
+	out := make([]any, mt.Type.NumOut())
+	for j := range out {
+		typ := mt.Type.Out(j)
+		val := reflect.Zero(typ)
+		if j < len(results) && results[j] != nil {
+			rv := reflect.ValueOf(results[j])
+			// untyped constants, e.g. 1 for an int64
+			if !rv.Type().AssignableTo(typ) && isNumber(rv.Kind()) && isNumber(typ.Kind()) {
+				rv = rv.Convert(typ)
+			}
+			val = rv
+		}
+		out[j] = val.Interface()
+	}
+	return out
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Complex128
+}
+
+func matchArgs(want, args []any) bool {
+	if len(want) == 0 {
+		return true
+	}
+	if len(want) != len(args) {
+		return false
+	}
+	for j, w := range want {
+		if w != Any && !reflect.DeepEqual(w, args[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Calls returns the calls of method recorded so far, or all the calls if
+// method is empty.
+func (m *Mock) Calls(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var calls []Call
+	for _, c := range m.calls {
+		if method == "" || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func (m *Mock) called(method string, args []any) bool {
+	for _, c := range m.Calls(method) {
+		if matchArgs(args, c.Args) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertCalled reports an error to t unless method was called with args,
+// or at all if no args are passed.
+func (m *Mock) AssertCalled(t testing.TB, method string, args ...any) bool {
+	t.Helper()
+	if !m.called(method, args) {
+		t.Errorf("%v.%v should be called with %v, calls: %v", m.iface, method, args, m.Calls(method))
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled reports an error to t if method was called with args, or
+// at all if no args are passed.
+func (m *Mock) AssertNotCalled(t testing.TB, method string, args ...any) bool {
+	t.Helper()
+	if m.called(method, args) {
+		t.Errorf("%v.%v should not be called with %v, calls: %v", m.iface, method, args, m.Calls(method))
+		return false
+	}
+	return true
+}
+
+// AssertNumberOfCalls reports an error to t unless method was called n
+// times.
+func (m *Mock) AssertNumberOfCalls(t testing.TB, method string, n int) bool {
+	t.Helper()
+	if calls := m.Calls(method); len(calls) != n {
+		t.Errorf("%v.%v should be called %d times, got %d", m.iface, method, n, len(calls))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheelstest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rame2015/wheels"
+	"github.com/stretchr/testify/assert"
+)
+
+type Cache interface {
+	Get(key string) (int64, error)
+	Set(key string, val int64, tags ...string)
+}
+
+type cacheProxy struct {
+	h wheels.ProxyHandler
+}
+
+func (p cacheProxy) Get(a0 string) (int64, error) {
+	out := p.h("Get", a0)
+	r0, _ := out[0].(int64)
+	r1, _ := out[1].(error)
+	return r0, r1
+}
+
+func (p cacheProxy) Set(a0 string, a1 int64, a2 ...string) {
+	p.h("Set", a0, a1, a2)
+}
+
+type storeProxy struct {
+	h wheels.ProxyHandler
+}
+
+func (p storeProxy) Name() string {
+	out := p.h("Name")
+	r0, _ := out[0].(string)
+	return r0
+}
+
+func init() {
+	wheels.RegisterProxy(func(h wheels.ProxyHandler) Cache { return cacheProxy{h} })
+	wheels.RegisterProxy(func(h wheels.ProxyHandler) Store { return storeProxy{h} })
+}
+
+type Counter struct {
+	cache Cache
+	store Store
+}
+
+func (c *Counter) Incr(key string) (int64, error) {
+	n, err := c.cache.Get(key)
+	if err != nil {
+		return 0, fmt.Errorf("%v: %w", c.store.Name(), err)
+	}
+	c.cache.Set(key, n+1, "counter")
+	return n + 1, nil
+}
+
+var errMiss = errors.New("miss")
+
+func TestMock(t *testing.T) {
+	cache, m := NewMock[Cache](t)
+	m.On("Get", "a").Return(1).Times(1)
+	m.On("Get", "a").Return(nil, errMiss)
+	m.On("Get", Any).Do(func(args ...any) []any {
+		return []any{int64(len(args[0].(string)))}
+	})
+
+	n, err := cache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = cache.Get("a")
+	assert.ErrorIs(t, err, errMiss)
+	n, _ = cache.Get("abc")
+	assert.Equal(t, int64(3), n)
+	cache.Set("b", 2, "x", "y")
+
+	assert.Len(t, m.Calls(""), 4)
+	assert.Equal(t, []Call{
+		{Method: "Set", Args: []any{"b", int64(2), []string{"x", "y"}}, Results: []any{}},
+	}, m.Calls("Set"))
+	m.AssertCalled(t, "Get", "abc")
+	m.AssertCalled(t, "Set", "b", Any, []string{"x", "y"})
+	m.AssertNotCalled(t, "Get", "c")
+	m.AssertNumberOfCalls(t, "Get", 3)
+
+	rec := &recorder{TB: t}
+	assert.False(t, m.AssertCalled(rec, "Set", "c"))
+	assert.False(t, m.AssertNotCalled(rec, "Get"))
+	assert.False(t, m.AssertNumberOfCalls(rec, "Set", 2))
+	assert.Len(t, rec.errors, 3)
+	assert.Equal(t, "wheelstest.Cache.Set should be called 2 times, got 1", rec.errors[2])
+
+	assert.Panics(t, func() { m.On("Del") })
+}
+
+func TestMockDependencies(t *testing.T) {
+	inj := wheels.New()
+	_ = inj.Provide(func(cache Cache, store Store) *Counter {
+		return &Counter{cache: cache, store: store}
+	})
+
+	t.Run("mocked", func(t *testing.T) {
+		mocks := MockDependencies(t, inj, "*wheelstest.Counter")
+		assert.Len(t, mocks, 2)
+		mocks["wheelstest.Cache"].On("Get").Return(41)
+		mocks["wheelstest.Store"].On("Name").Return("redis")
+
+		c, err := wheels.InvokeFrom[*Counter](inj)
+		assert.NoError(t, err)
+		n, err := c.Incr("hits")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), n)
+		mocks["wheelstest.Cache"].AssertCalled(t, "Set", "hits", int64(42), []string{"counter"})
+
+		m := InjectMock[Cache](t, inj)
+		m.On("Get").Return(0, errMiss)
+		c, _ = wheels.InvokeFrom[*Counter](inj)
+		_, err = c.Incr("hits")
+		assert.EqualError(t, err, "redis: miss")
+		m.AssertNotCalled(t, "Set")
+	})
+
+	_, err := inj.Invoke("*wheelstest.Counter")
+	assert.ErrorIs(t, err, wheels.ErrUnknownService)
+	_, err = inj.Invoke("wheelstest.Cache")
+	assert.ErrorIs(t, err, wheels.ErrUnknownService)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package wheelstest provides helpers to test code that uses a
+// wheels.Injector, such as the default one, without leaking services and
+// instances across tests.
+package wheelstest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rame2015/wheels"
+)
+
+// Snapshot saves the services and the built instances of inj, call Restore
+// on the result to bring them back.
+func Snapshot(inj *wheels.Injector) *wheels.Snapshot {
+	return inj.Snapshot()
+}
+
+// Isolate saves inj and restores it when t and its subtests complete, so
+// the overrides made by the test do not leak into the next ones. It
+// returns inj.
+func Isolate(t testing.TB, inj *wheels.Injector) *wheels.Injector {
+	t.Helper()
+	t.Cleanup(inj.Snapshot().Restore)
+	return inj
+}
+
+// AssertBuilt reports an error to t unless the service name of inj is
+// built.
+func AssertBuilt(t testing.TB, inj *wheels.Injector, name string) bool {
+	t.Helper()
+	if _, err := inj.Invoke(name, wheels.WithoutBuild()); err != nil {
+		t.Errorf("service %v should be built: %v", name, err)
+		return false
+	}
+	return true
+}
+
+// AssertNotBuilt reports an error to t if the service name of inj is built
+// or not provided.
+func AssertNotBuilt(t testing.TB, inj *wheels.Injector, name string) bool {
+	t.Helper()
+	_, err := inj.Invoke(name, wheels.WithoutBuild())
+	if err == nil {
+		t.Errorf("service %v should not be built", name)
+		return false
+	}
+	if !errors.Is(err, wheels.ErrNotBuilt) {
+		t.Errorf("service %v should not be built: %v", name, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+/*
+Copyright (c) 2023, rame2015
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wheelstest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rame2015/wheels"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct{ DSN string }
+
+type Store interface{ Name() string }
+
+type DB struct{ cfg *Config }
+
+func (d *DB) Name() string { return d.cfg.DSN }
+
+type Repo struct {
+	Store Store
+}
+
+func newInjector() *wheels.Injector {
+	inj := wheels.New()
+	_ = inj.ProvideInstance(&Config{DSN: "prod"})
+	_ = inj.Provide(func(cfg *Config) *DB { return &DB{cfg: cfg} }, wheels.As(new(Store)))
+	_ = inj.ProvideZero(&Repo{})
+	return inj
+}
+
+func TestIsolate(t *testing.T) {
+	inj := newInjector()
+	repo, err := wheels.InvokeFrom[*Repo](inj)
+	assert.NoError(t, err)
+
+	t.Run("override", func(t *testing.T) {
+		Isolate(t, inj)
+		_ = inj.OverrideInstance(&Config{DSN: "test"})
+		_ = inj.ProvideInstance(&DB{}, wheels.Name("extra"))
+		AssertNotBuilt(t, inj, "*wheelstest.Repo")
+		r, err := wheels.InvokeFrom[*Repo](inj)
+		assert.NoError(t, err)
+		assert.Equal(t, "test", r.Store.Name())
+	})
+
+	AssertBuilt(t, inj, "*wheelstest.Repo")
+	r, err := wheels.InvokeFrom[*Repo](inj)
+	assert.NoError(t, err)
+	assert.Same(t, repo, r)
+	assert.Equal(t, "prod", r.Store.Name())
+	_, err = inj.Invoke("extra")
+	assert.ErrorIs(t, err, wheels.ErrUnknownService)
+
+	// the services reset after the snapshot are built again from the
+	// restored ones
+	_ = inj.OverrideInstance(&Config{DSN: "prod"})
+	r, err = wheels.InvokeFrom[*Repo](inj)
+	assert.NoError(t, err)
+	assert.NotSame(t, repo, r)
+}
+
+func TestSnapshot(t *testing.T) {
+	inj := newInjector()
+	snap := Snapshot(inj)
+	_, err := inj.Invoke("*wheelstest.DB")
+	assert.NoError(t, err)
+	AssertBuilt(t, inj, "*wheelstest.DB")
+
+	snap.Restore()
+	AssertNotBuilt(t, inj, "*wheelstest.DB")
+	AssertBuilt(t, inj, "*wheelstest.Config")
+
+	_ = inj.Decorate(func(cfg *Config) *Config { return &Config{DSN: "decorated"} })
+	db, _ := wheels.InvokeFrom[*DB](inj)
+	assert.Equal(t, "decorated", db.Name())
+	snap.Restore()
+	db, _ = wheels.InvokeFrom[*DB](inj)
+	assert.Equal(t, "prod", db.Name())
+}
+
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertBuilt(t *testing.T) {
+	inj := newInjector()
+	rec := &recorder{TB: t}
+	assert.False(t, AssertBuilt(rec, inj, "*wheelstest.DB"))
+	assert.True(t, AssertNotBuilt(rec, inj, "*wheelstest.DB"))
+	assert.False(t, AssertNotBuilt(rec, inj, "unknown"))
+	_, _ = inj.Invoke("*wheelstest.DB")
+	assert.True(t, AssertBuilt(rec, inj, "*wheelstest.DB"))
+	assert.False(t, AssertNotBuilt(rec, inj, "*wheelstest.DB"))
+	assert.Equal(t, []string{
+		"service *wheelstest.DB should be built: name: *wheelstest.DB, err: service not built",
+		"service unknown should not be built: name: unknown, err: unknown service",
+		"service *wheelstest.DB should not be built",
+	}, rec.errors)
+}